@@ -138,6 +138,14 @@ func SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
 	return client.SAdd(ctx, key, members...)
 }
 
+func SRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	span, err := doTracing(ctx, spanTag{"cmd", "SRem"}, spanTag{"key", key})
+	if err == nil {
+		defer span.End(ctx)
+	}
+	return client.SRem(ctx, key, members...)
+}
+
 func SIsMember(ctx context.Context, key string, member interface{}) *redis.BoolCmd {
 	span, err := doTracing(ctx, spanTag{"cmd", "SIsMember"}, spanTag{"key", key})
 	if err == nil {
@@ -13,14 +13,16 @@ type IChain interface {
 }
 
 type ActorContext interface {
-	// Call performs a blocking call to target actor
+	// Call performs a blocking call to target actor, it returns once the handler replied
+	// (the handler returned, or called mw.Reply after mw.DeferReply), not once the messages it sent are handled
 	//
 	// Parameters:
 	//   - idOrSymbol: target actorID, or routing rule symbol to target actor
 	//   - actorType: type of actor, obtained from actor template
 	//   - event: event name to be handled
 	//   - mw: message wrapper for routing
-	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error
+	//   - opts: per-call options (timeout, retries, priority ...), see CallWithTimeout
+	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) error
 
 	// ReenterCall performs a reentrant(asynchronous) call
 	//
@@ -29,7 +31,8 @@ type ActorContext interface {
 	//   - actorType: type of actor, obtained from actor template
 	//   - event: event name to be handled
 	//   - mw: message wrapper for routing
-	ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper) IFuture
+	//   - opts: per-call options, the timeout of the call defaults to 30s
+	ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) IFuture
 
 	// Send performs an asynchronous call
 	//
@@ -38,7 +41,8 @@ type ActorContext interface {
 	//   - actorType: type of actor, obtained from actor template
 	//   - event: event name to be handled
 	//   - mw: message wrapper for routing
-	Send(idOrSymbol, actorType, event string, mw *msg.Wrapper) error
+	//   - opts: per-call options, a timeout bounds the time the message may wait before it is handled
+	Send(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) error
 
 	// Pub semantics for pubsub, used to publish messages to an actor's message cache queue
 	Pub(topic string, event string, body []byte) error
@@ -55,6 +59,48 @@ type ActorContext interface {
 	// Unregister unregisters an actor
 	Unregister(id, ty string) error
 
+	// JoinGroup adds the current actor to a named group, messages sent to "#" + group are delivered to every member
+	JoinGroup(group string) error
+
+	// LeaveGroup removes the current actor from a named group
+	LeaveGroup(group string) error
+
+	// RegisterReminder registers a durable reminder of the current actor, event fires into the mailbox after due,
+	// and then every period if period > 0. Reminders are persisted in redis and survive node restarts
+	RegisterReminder(name, event string, due, period time.Duration, body []byte) error
+
+	// Reminders lists the reminders of the current actor
+	Reminders() ([]Reminder, error)
+
+	// CancelReminder cancels a reminder of the current actor
+	CancelReminder(name string) error
+
+	// Spawn registers a child of the current actor on this node, the child is unregistered with its parent
+	// and escalates its failures to the parent's supervisor
+	Spawn(actorType, id string, opts ...SpawnOption) (IActor, error)
+
+	// Children returns the children spawned by the current actor which are still registered
+	Children() []IActor
+
+	// Watch subscribes the current actor to the termination of another actor, a Terminated is delivered
+	// as a TerminatedEvent into the mailbox. If the actor is not registered, Terminated is delivered at once
+	Watch(id string) error
+
+	// Unwatch stops watching an actor
+	Unwatch(id string) error
+
+	// Stash defers the message being handled, until UnstashAll is called. It must be called from a handler
+	Stash() error
+
+	// UnstashAll requeues the stashed messages in their original order, ahead of the pending messages
+	UnstashAll()
+
+	// Become replaces the whole event-chain map of the actor, e.g. to switch between loading, active and closing states
+	Become(chains map[string]IChain)
+
+	// Unbecome restores the event chains replaced by the last Become
+	Unbecome()
+
 	ID() string
 	Type() string
 
@@ -77,11 +123,28 @@ type ActorContext interface {
 	GetValue(key interface{}) interface{}
 }
 
+// IFuture is the result of a ReenterCall, its continuations run on the goroutine of the actor that made the call
 type IFuture interface {
 	Complete(*msg.Wrapper)
 	IsCompleted() bool
 
+	// Then registers a continuation, the returned future completes once the continuation has run
 	Then(func(*msg.Wrapper)) IFuture
+
+	// All returns a future which completes once this future and all the others have completed,
+	// its result carries the errors of the joined results
+	All(others ...IFuture) IFuture
+
+	// Any returns a future which completes with the first result of this future or the others
+	Any(others ...IFuture) IFuture
+
+	// WithTimeout returns a future which completes with ErrFutureTimeout if no result arrived in time,
+	// the pending call is cancelled on timeout
+	WithTimeout(timeout time.Duration) IFuture
+
+	// Cancel completes the future with ErrFutureCanceled and cancels the pending call
+	// Returns false if the future was already completed
+	Cancel() bool
 }
 
 // ITimer interface for timer operations
@@ -132,9 +195,35 @@ type IActor interface {
 	//  args: can be used to pass the actor entity to the timer callback
 	OnTimer(dueTime int64, interval int64, f func(interface{}) error, args interface{}) ITimer
 
+	// OnCron registers a timer driven by a cron expression, the callback runs on the actor goroutine like OnTimer
+	//  expr: "second minute hour day month weekday" (the 5 field form without seconds is also accepted)
+	//  tz: time zone the expression is evaluated in (DST aware), nil for the local time zone
+	//  opts: missed-fire policy, see CronWithMisfire
+	OnCron(expr string, tz *time.Location, f func(interface{}) error, args interface{}, opts ...CronOption) (ITimer, error)
+
 	// CancelTimer cancels a timer
 	CancelTimer(t ITimer)
 
+	// OnPassivate registers a hook that runs on the actor goroutine before an idle actor is passivated
+	//  returning an error keeps the actor alive until the next idle check
+	OnPassivate(f func() error)
+
+	// OnStart registers a hook that runs on the actor goroutine once the actor is registered, before it handles any message
+	//  returning an error (or timing out) aborts the registration
+	OnStart(f func() error)
+
+	// OnStop registers a hook that runs on the actor goroutine when the actor exits,
+	// after its mailbox is drained and before its timers are cancelled
+	OnStop(f func() error)
+
+	// OnRestart registers a hook that runs on the fresh instance of an actor restarted by its supervisor, instead of OnStart
+	//  returning an error stops the actor
+	OnRestart(f func(Failure) error)
+
+	// Start runs the OnStart hook, it is called by the system once the actor is registered
+	//  the actor does not handle messages before it has started
+	Start(ctx context.Context) error
+
 	// SubscriptionEvent subscribes to a message
 	//  If this is the first subscription to this topic, opts will take effect (you can set some options for the topic, such as ttl)
 	//  topic: A subject that contains a group of channels (e.g., if topic = offline messages, channel = actorId, then each actor can get its own offline messages in this topic)
@@ -143,9 +232,9 @@ type IActor interface {
 	Sub(topic string, channel string, createChainF func(ActorContext) IChain, opts ...pubsub.TopicOption) error
 
 	// Call sends an event to another actor
-	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error
+	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) error
 
-	ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper) IFuture
+	ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) IFuture
 
 	Context() ActorContext
 
@@ -169,6 +258,13 @@ type IActorBuilder interface {
 	GetGlobalQuantityLimit() int
 	GetNodeUnique() bool
 	GetWeight() int
+	GetMailboxCapacity() int
+	GetMailboxOverflow() MailboxOverflowPolicy
+	GetSupervisor() *SupervisorStrategy
+	GetIdleTimeout() time.Duration
+	GetDispatcher() IDispatcher
+	GetParent() string
+	GetLocal() bool
 	GetOpt(key string) string
 	GetOptions() map[string]string
 
@@ -180,6 +276,13 @@ type IActorBuilder interface {
 	WithID(string) IActorBuilder
 	WithType(string) IActorBuilder
 	WithOpt(string, string) IActorBuilder
+	WithMailbox(capacity int, policy MailboxOverflowPolicy) IActorBuilder
+	WithSupervisor(*SupervisorStrategy) IActorBuilder
+	WithIdleTimeout(time.Duration) IActorBuilder
+	// WithParent makes the actor a child of a local actor
+	WithParent(id string) IActorBuilder
+	// WithLocal registers the actor on this node only, skipping the address book
+	WithLocal() IActorBuilder
 
 	// ---
 	Register(context.Context) (IActor, error)
@@ -204,9 +307,47 @@ type ActorConstructor struct {
 	// Global quantity limit for the current actor type that can be registered
 	GlobalQuantityLimit int
 
+	// MailboxCapacity limits the number of pending messages in the actor's mailbox, 0 means unbounded
+	MailboxCapacity int
+
+	// MailboxOverflow is the policy applied when a message arrives at a full mailbox
+	MailboxOverflow MailboxOverflowPolicy
+
+	// Supervisor decides what happens to the actor when a handler or timer panics, nil means resume
+	Supervisor *SupervisorStrategy
+
+	// IdleTimeout passivates the actor once it has handled no message or timer for this long, 0 keeps it alive until unregistered
+	IdleTimeout time.Duration
+
+	// Dispatcher runs the actors of this type in turns on a shared pool of workers, nil runs each actor on its own goroutine
+	Dispatcher IDispatcher
+
+	// ActivateOnDemand creates the actor through IActorLoader.Pick when a call or send targets an unknown id of this type
+	ActivateOnDemand bool
+
 	Options map[string]string
 }
 
+// MailboxOverflowPolicy decides what happens to a message that arrives at a full mailbox
+type MailboxOverflowPolicy int
+
+const (
+	// MailboxBlock blocks the sender until there is room in the mailbox or the message context is done
+	MailboxBlock MailboxOverflowPolicy = iota
+
+	// MailboxReject rejects the message, Received returns ErrMailboxFull
+	MailboxReject
+
+	// MailboxDropOldest discards the oldest pending message to make room for the new one
+	MailboxDropOldest
+
+	// MailboxDropNewest silently discards the incoming message
+	MailboxDropNewest
+)
+
+// ActorBuilderKey is the context key under which the system passes the actor builder to IActor.Init
+type ActorBuilderKey struct{}
+
 type IActorFactory interface {
 	Get(ty string) *ActorConstructor
 	GetActors() []*ActorConstructor
@@ -3,6 +3,7 @@ package actor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/pojol/braid/core"
 )
@@ -13,6 +14,9 @@ type ActorLoaderBuilder struct {
 	core.ActorConstructor
 	core.IActorLoader
 
+	parent string
+	local  bool
+
 	optionsMutex sync.RWMutex
 }
 
@@ -36,6 +40,32 @@ func (p *ActorLoaderBuilder) WithOpt(key string, value string) core.IActorBuilde
 	return p
 }
 
+func (p *ActorLoaderBuilder) WithMailbox(capacity int, policy core.MailboxOverflowPolicy) core.IActorBuilder {
+	p.MailboxCapacity = capacity
+	p.MailboxOverflow = policy
+	return p
+}
+
+func (p *ActorLoaderBuilder) WithSupervisor(strategy *core.SupervisorStrategy) core.IActorBuilder {
+	p.Supervisor = strategy
+	return p
+}
+
+func (p *ActorLoaderBuilder) WithIdleTimeout(timeout time.Duration) core.IActorBuilder {
+	p.IdleTimeout = timeout
+	return p
+}
+
+func (p *ActorLoaderBuilder) WithParent(id string) core.IActorBuilder {
+	p.parent = id
+	return p
+}
+
+func (p *ActorLoaderBuilder) WithLocal() core.IActorBuilder {
+	p.local = true
+	return p
+}
+
 func (p *ActorLoaderBuilder) GetID() string {
 	return p.ID
 }
@@ -56,6 +86,34 @@ func (p *ActorLoaderBuilder) GetNodeUnique() bool {
 	return p.NodeUnique
 }
 
+func (p *ActorLoaderBuilder) GetMailboxCapacity() int {
+	return p.MailboxCapacity
+}
+
+func (p *ActorLoaderBuilder) GetMailboxOverflow() core.MailboxOverflowPolicy {
+	return p.MailboxOverflow
+}
+
+func (p *ActorLoaderBuilder) GetSupervisor() *core.SupervisorStrategy {
+	return p.Supervisor
+}
+
+func (p *ActorLoaderBuilder) GetIdleTimeout() time.Duration {
+	return p.IdleTimeout
+}
+
+func (p *ActorLoaderBuilder) GetDispatcher() core.IDispatcher {
+	return p.Dispatcher
+}
+
+func (p *ActorLoaderBuilder) GetParent() string {
+	return p.parent
+}
+
+func (p *ActorLoaderBuilder) GetLocal() bool {
+	return p.local
+}
+
 func (p *ActorLoaderBuilder) GetOptions() map[string]string {
 	p.optionsMutex.RLock()
 	defer p.optionsMutex.RUnlock()
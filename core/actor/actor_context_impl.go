@@ -3,6 +3,8 @@ package actor
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/pojol/braid/core"
 	"github.com/pojol/braid/router/msg"
@@ -12,13 +14,13 @@ type actorContext struct {
 	ctx context.Context
 }
 
-func (ac *actorContext) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error {
+func (ac *actorContext) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error {
 	actor, ok := ac.ctx.Value(actorKey{}).(core.IActor)
 	if !ok {
 		panic(errors.New("the actor instance does not exist in the ActorContext"))
 	}
 
-	return actor.Call(idOrSymbol, actorType, event, mw)
+	return actor.Call(idOrSymbol, actorType, event, mw, opts...)
 }
 
 func (ac *actorContext) ID() string {
@@ -39,22 +41,22 @@ func (ac *actorContext) Type() string {
 	return actor.Type()
 }
 
-func (ac *actorContext) ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper) core.IFuture {
+func (ac *actorContext) ReenterCall(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) core.IFuture {
 	actor, ok := ac.ctx.Value(actorKey{}).(core.IActor)
 	if !ok {
 		panic(errors.New("the actor instance does not exist in the ActorContext"))
 	}
 
-	return actor.ReenterCall(idOrSymbol, actorType, event, mw)
+	return actor.ReenterCall(idOrSymbol, actorType, event, mw, opts...)
 }
 
-func (ac *actorContext) Send(idOrSymbol, actorType, event string, mw *msg.Wrapper) error {
+func (ac *actorContext) Send(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error {
 	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
 	if !ok {
 		panic(errors.New("the system instance does not exist in the ActorContext"))
 	}
 
-	return sys.Send(idOrSymbol, actorType, event, mw)
+	return sys.Send(idOrSymbol, actorType, event, mw, opts...)
 }
 
 func (ac *actorContext) Unregister(id, ty string) error {
@@ -66,6 +68,24 @@ func (ac *actorContext) Unregister(id, ty string) error {
 	return sys.Unregister(id, ty)
 }
 
+func (ac *actorContext) JoinGroup(group string) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.AddressBook().JoinGroup(context.TODO(), group, ac.ID())
+}
+
+func (ac *actorContext) LeaveGroup(group string) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.AddressBook().LeaveGroup(context.TODO(), group, ac.ID())
+}
+
 func (ac *actorContext) Pub(topic, event string, body []byte) error {
 	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
 	if !ok {
@@ -109,3 +129,128 @@ func (ac *actorContext) WithValue(key, value interface{}) {
 func (ac *actorContext) GetValue(key interface{}) interface{} {
 	return ac.ctx.Value(key)
 }
+
+func (ac *actorContext) RegisterReminder(name, event string, due, period time.Duration, body []byte) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.RegisterReminder(context.TODO(), core.Reminder{
+		ActorID: ac.ID(),
+		ActorTy: ac.Type(),
+		Name:    name,
+		Event:   event,
+		Due:     time.Now().Add(due),
+		Period:  period,
+		Body:    body,
+	})
+}
+
+func (ac *actorContext) Reminders() ([]core.Reminder, error) {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.Reminders(context.TODO(), ac.ID())
+}
+
+func (ac *actorContext) CancelReminder(name string) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.CancelReminder(context.TODO(), ac.ID(), name)
+}
+
+func (ac *actorContext) Watch(id string) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.Watch(context.TODO(), ac.ID(), ac.Type(), id)
+}
+
+func (ac *actorContext) Unwatch(id string) error {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.Unwatch(context.TODO(), ac.ID(), id)
+}
+
+func (ac *actorContext) Spawn(actorType, id string, opts ...core.SpawnOption) (core.IActor, error) {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	p := core.SpawnParm{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	builder := sys.Loader(actorType)
+	if builder == nil {
+		return nil, fmt.Errorf("[braid.actor] %v spawn unknown actor type %v", ac.ID(), actorType)
+	}
+
+	builder.WithID(id).WithParent(ac.ID())
+	if p.Local {
+		builder.WithLocal()
+	}
+	if p.Supervisor != nil {
+		builder.WithSupervisor(p.Supervisor)
+	}
+
+	return builder.Register(context.TODO())
+}
+
+func (ac *actorContext) Children() []core.IActor {
+	sys, ok := ac.ctx.Value(systemKey{}).(core.ISystem)
+	if !ok {
+		panic(errors.New("the system instance does not exist in the ActorContext"))
+	}
+
+	return sys.Children(ac.ID())
+}
+
+func (ac *actorContext) Stash() error {
+	actor, ok := ac.ctx.Value(actorKey{}).(*Runtime)
+	if !ok {
+		panic(errors.New("the actor instance does not exist in the ActorContext"))
+	}
+
+	return actor.Stash()
+}
+
+func (ac *actorContext) UnstashAll() {
+	actor, ok := ac.ctx.Value(actorKey{}).(*Runtime)
+	if !ok {
+		panic(errors.New("the actor instance does not exist in the ActorContext"))
+	}
+
+	actor.UnstashAll()
+}
+
+func (ac *actorContext) Become(chains map[string]core.IChain) {
+	actor, ok := ac.ctx.Value(actorKey{}).(*Runtime)
+	if !ok {
+		panic(errors.New("the actor instance does not exist in the ActorContext"))
+	}
+
+	actor.Become(chains)
+}
+
+func (ac *actorContext) Unbecome() {
+	actor, ok := ac.ctx.Value(actorKey{}).(*Runtime)
+	if !ok {
+		panic(errors.New("the actor instance does not exist in the ActorContext"))
+	}
+
+	actor.Unbecome()
+}
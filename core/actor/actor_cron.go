@@ -0,0 +1,90 @@
+package actor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/lib/timer"
+)
+
+// cronSchedule is attached to the timers registered with OnCron, each fire is scheduled as a one-shot on the timing wheel
+type cronSchedule struct {
+	express *timer.CronExpress
+	loc     *time.Location
+	parm    core.CronParm
+	next    time.Time // wall time of the pending fire
+}
+
+// OnCron registers a timer driven by a cron expression
+//
+//	expr: "second minute hour day month weekday" (the 5 field form without seconds is also accepted)
+//	tz: time zone the expression is evaluated in, nil for the local time zone
+//	f: callback function, it runs on the actor goroutine
+//	args: can be used to pass the actor entity to the timer callback
+//	opts: missed-fire policy, defaults to firing once on catch-up
+func (a *Runtime) OnCron(expr string, tz *time.Location, f func(interface{}) error, args interface{}, opts ...core.CronOption) (core.ITimer, error) {
+	express, err := timer.ParseCron(expr)
+	if err != nil {
+		return nil, fmt.Errorf("actor: register cron err %w", err)
+	}
+
+	if tz == nil {
+		tz = time.Local
+	}
+
+	parm := core.CronParm{
+		Misfire:          core.CronMisfireFireOnce,
+		MisfireThreshold: core.DefaultCronMisfireThreshold,
+	}
+	for _, opt := range opts {
+		opt(&parm)
+	}
+
+	info := NewTimerInfo(0, 0, f, args)
+	info.wheel = a.Sys.TimingWheel()
+	info.queue = a.timerQueue
+	info.cron = &cronSchedule{express: express, loc: tz, parm: parm}
+
+	a.timers[info] = struct{}{}
+
+	if !info.scheduleCron(time.Now()) {
+		delete(a.timers, info)
+		return nil, fmt.Errorf("actor: cron %v never fires", expr)
+	}
+
+	return info, nil
+}
+
+// scheduleCron arms the timer for the first fire after the given time
+func (t *TimerInfo) scheduleCron(after time.Time) bool {
+	next := t.cron.express.Next(after.In(t.cron.loc))
+	if next.IsZero() {
+		t.active.Store(false)
+		return false
+	}
+
+	t.cron.next = next
+	t.schedule(time.Until(next))
+	return true
+}
+
+// cronDue checks a cron expiration on the actor goroutine, returns false if the callback must not run
+func (a *Runtime) cronDue(t *TimerInfo) bool {
+	now := time.Now()
+
+	// the wall clock was moved back after the fire was scheduled
+	if now.Before(t.cron.next) {
+		t.schedule(t.cron.next.Sub(now))
+		return false
+	}
+
+	if late := now.Sub(t.cron.next); late > t.cron.parm.MisfireThreshold && t.cron.parm.Misfire == core.CronMisfireSkip {
+		log.WarnF("[braid.timer] %v cron %v fire at %v missed by %v, skipped", a.Id, t.cron.express, t.cron.next, late)
+		t.scheduleCron(now)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,226 @@
+package actor
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pojol/braid/lib/log"
+)
+
+type DispatcherParm struct {
+	// Workers is the number of goroutines shared by the actors of the dispatcher, default runtime.NumCPU()
+	//  a handler blocked in a synchronous Call holds its worker until the call returns
+	Workers int
+
+	// Throughput is the number of items an actor handles in a turn before it yields its worker, default 16
+	Throughput int
+}
+
+type DispatcherOption func(*DispatcherParm)
+
+func DispatcherWithWorkers(workers int) DispatcherOption {
+	return func(p *DispatcherParm) {
+		p.Workers = workers
+	}
+}
+
+func DispatcherWithThroughput(throughput int) DispatcherOption {
+	return func(p *DispatcherParm) {
+		p.Throughput = throughput
+	}
+}
+
+// Dispatcher is a fixed pool of workers running the turns of the scheduled actors in FIFO order,
+// select it for an actor type with core.ActorConstructor.Dispatcher
+type Dispatcher struct {
+	parm DispatcherParm
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	turns []func(int) bool
+	exit  bool
+	wg    sync.WaitGroup
+}
+
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	p := DispatcherParm{
+		Workers:    runtime.NumCPU(),
+		Throughput: 16,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.Workers <= 0 || p.Throughput <= 0 {
+		panic(fmt.Errorf("[braid.dispatcher] workers %v throughput %v must be positive", p.Workers, p.Throughput))
+	}
+
+	d := &Dispatcher{parm: p}
+	d.cond = sync.NewCond(&d.mu)
+
+	d.wg.Add(p.Workers)
+	for i := 0; i < p.Workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+func (d *Dispatcher) Schedule(turn func(throughput int) bool) {
+	d.mu.Lock()
+	d.turns = append(d.turns, turn)
+	d.mu.Unlock()
+	d.cond.Signal()
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.turns) == 0 && !d.exit {
+			d.cond.Wait()
+		}
+		if len(d.turns) == 0 {
+			d.mu.Unlock()
+			return
+		}
+		turn := d.turns[0]
+		d.turns[0] = nil
+		d.turns = d.turns[1:]
+		d.mu.Unlock()
+
+		if turn(d.parm.Throughput) {
+			d.Schedule(turn)
+		}
+	}
+}
+
+// Exit stops the workers once the scheduled turns have run, the actors of the dispatcher must have exited
+func (d *Dispatcher) Exit() {
+	d.mu.Lock()
+	d.exit = true
+	d.mu.Unlock()
+	d.cond.Broadcast()
+
+	d.wg.Wait()
+}
+
+// phase of an actor run by a dispatcher, it is only read and written by the turns of the actor
+type dispatchPhase int
+
+const (
+	phaseStarting dispatchPhase = iota
+	phaseRunning
+	phaseFailed  // the start hook failed, messages are rejected once the actor exits
+	phaseStopped // the actor never runs again
+)
+
+// control items of an actor run by a dispatcher, they replace the channels the update goroutine selects on
+type shutdownSignal struct{}
+type idleSignal struct{}
+
+// schedule queues a turn unless the actor is already scheduled, it is called after each push to the actor's queues
+func (a *Runtime) schedule() {
+	if atomic.CompareAndSwapInt32(&a.scheduled, 0, 1) {
+		a.dispatcher.Schedule(a.turn)
+	}
+}
+
+// turn runs the actor on a dispatcher worker, the scheduled flag guarantees turns never overlap
+func (a *Runtime) turn(throughput int) bool {
+	a.runTurn(throughput)
+	if a.phase == phaseStopped {
+		return false // the actor stays scheduled, no push can queue another turn
+	}
+	running := a.phase == phaseRunning
+
+	atomic.StoreInt32(&a.scheduled, 0)
+
+	// a push which saw the actor scheduled did not queue a turn, keep the work it brought.
+	// only the counters are read here, a new turn may already be running on another worker
+	pending := a.ctrlQueue.Count() > 0
+	if running {
+		pending = pending || a.q.Count() > 0 || a.urgentQueue.Count() > 0 || a.reenterQueue.Count() > 0 || a.unstashQueue.Count() > 0 || a.timerQueue.Count() > 0
+	}
+
+	return pending && atomic.CompareAndSwapInt32(&a.scheduled, 0, 1)
+}
+
+func (a *Runtime) runTurn(throughput int) {
+	for v := a.ctrlQueue.Pop(); v != nil; v = a.ctrlQueue.Pop() {
+		switch c := v.(type) {
+		case startRequest:
+			if a.phase != phaseStarting {
+				c.result <- fmt.Errorf("actor %v exited before start", a.Id)
+			} else if a.handleStart(c) {
+				a.run()
+			} else {
+				a.phase = phaseFailed
+				atomic.StoreInt32(&a.closed, 2)
+			}
+		case shutdownSignal:
+			if a.phase == phaseFailed {
+				a.reject()
+				a.stop()
+				return
+			}
+			if a.phase == phaseStarting {
+				a.run()
+			}
+			if atomic.CompareAndSwapInt32(&a.closed, 0, 1) {
+				log.DebugF("[braid.actor] %s exiting check close %v", a.Id, atomic.LoadInt32(&a.closed))
+			}
+		case idleSignal:
+			if a.phase == phaseRunning && atomic.LoadInt32(&a.closed) == 0 {
+				a.idle.Reset(a.checkIdle())
+			}
+		}
+	}
+
+	if a.phase != phaseRunning {
+		return
+	}
+
+	for n := 0; n < throughput; n++ {
+		served, restarted := a.next()
+		if restarted {
+			a.stop() // the restart hands the pending messages over, the stop hook does not run
+			return
+		}
+		if !served {
+			break
+		}
+	}
+
+	// the actor is closing and its mailbox is drained
+	if atomic.LoadInt32(&a.closed) == 1 && a.q.Empty() && a.urgentQueue.Empty() && a.reenterQueue.Empty() && a.unstashQueue.Empty() {
+		log.InfoF("[braid.actor] %s closing channel", a.Id)
+		atomic.StoreInt32(&a.closed, 2)
+		close(a.closeCh)
+		a.runHook("stop", a.stopHook)
+		a.dropStash()
+		a.stop()
+	}
+}
+
+func (a *Runtime) run() {
+	a.phase = phaseRunning
+
+	// idle checks are control items, an idle actor is not scheduled until its idle timeout
+	if a.idleTimeout > 0 {
+		a.idle = time.AfterFunc(a.idleTimeout, func() {
+			a.ctrlQueue.Push(idleSignal{})
+		})
+	}
+}
+
+func (a *Runtime) stop() {
+	a.phase = phaseStopped
+	if a.idle != nil {
+		a.idle.Stop()
+	}
+	close(a.stoppedCh)
+}
@@ -0,0 +1,142 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+// restartHookTimeout bounds the OnRestart hook of a restarted actor
+const restartHookTimeout = 5 * time.Second
+
+// startRequest asks the actor goroutine to run its start hook, failure is set when the actor was restarted by its supervisor
+type startRequest struct {
+	ctx     context.Context
+	failure *core.Failure
+	result  chan error
+}
+
+// OnStart registers a hook that runs on the actor goroutine once the actor is registered, before it handles any message
+//
+//	returning an error (or panicking, or timing out) aborts the registration
+func (a *Runtime) OnStart(f func() error) {
+	a.startHook = f
+}
+
+// OnStop registers a hook that runs on the actor goroutine when the actor exits,
+// after its mailbox is drained and before its timers are cancelled
+//
+//	it does not run when the actor is restarted by its supervisor
+func (a *Runtime) OnStop(f func() error) {
+	a.stopHook = f
+}
+
+// OnRestart registers a hook that runs on the fresh instance of a restarted actor instead of OnStart,
+// before it handles the messages handed over by the failed instance
+//
+//	returning an error stops the actor
+func (a *Runtime) OnRestart(f func(core.Failure) error) {
+	a.restartHook = f
+}
+
+// Start runs the OnStart hook on the actor goroutine and waits for it until the context is done
+func (a *Runtime) Start(ctx context.Context) error {
+	return a.start(ctx, nil)
+}
+
+func (a *Runtime) start(ctx context.Context, failure *core.Failure) error {
+	req := startRequest{ctx: ctx, failure: failure, result: make(chan error, 1)}
+
+	if a.dispatcher != nil {
+		a.ctrlQueue.Push(req)
+		return a.awaitResult(ctx, req)
+	}
+
+	select {
+	case a.startCh <- req:
+	case <-a.shutdownCh:
+		return fmt.Errorf("actor %v exited before start", a.Id)
+	case <-ctx.Done():
+		return fmt.Errorf("actor %v start %w", a.Id, ctx.Err())
+	}
+
+	return a.awaitResult(ctx, req)
+}
+
+func (a *Runtime) awaitResult(ctx context.Context, req startRequest) error {
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("actor %v start hook timeout %w", a.Id, ctx.Err())
+	}
+}
+
+// awaitStart holds the mailbox until the actor is started, it runs on the actor goroutine
+//
+//	returns false if the start hook failed
+func (a *Runtime) awaitStart() bool {
+	select {
+	case req := <-a.startCh:
+		return a.handleStart(req)
+	case <-a.shutdownCh:
+		return true
+	}
+}
+
+// handleStart runs the start (or restart) hook of a start request, returns false if the hook failed
+func (a *Runtime) handleStart(req startRequest) bool {
+	var err error
+	if req.failure != nil && a.restartHook != nil {
+		err = a.runHook("restart", func() error { return a.restartHook(*req.failure) })
+	} else {
+		err = a.runHook("start", a.startHook)
+	}
+	if err == nil && req.ctx.Err() != nil {
+		err = fmt.Errorf("actor %v start hook timeout %w", a.Id, req.ctx.Err()) // the registration was already rolled back
+	}
+	req.result <- err
+	return err == nil
+}
+
+// abandon rejects the messages of an actor which failed to start, until the system exits it
+func (a *Runtime) abandon() {
+	<-a.shutdownCh
+	a.reject()
+}
+
+// reject dead-letters the pending messages of an actor which failed to start, and closes it
+func (a *Runtime) reject() {
+	for _, q := range []interface{ Pop() interface{} }{a.urgentQueue, a.q} {
+		for v := q.Pop(); v != nil; v = q.Pop() {
+			if mw, ok := v.(*msg.Wrapper); ok {
+				a.Sys.DeadLetter(mw, core.DeadLetterActorClosed, fmt.Errorf("actor %v failed to start", a.Id))
+				mw.Reply()
+			}
+		}
+	}
+
+	close(a.closeCh)
+}
+
+// runHook runs a lifecycle hook, a panic is returned as an error
+func (a *Runtime) runHook(name string, f func() error) (err error) {
+	if f == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("actor %v on %v panic: %v", a.Id, name, r)
+		}
+	}()
+
+	if err = f(); err != nil {
+		log.WarnF("[braid.actor] %v on %v err %v", a.Id, name, err)
+	}
+	return err
+}
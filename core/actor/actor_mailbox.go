@@ -0,0 +1,148 @@
+package actor
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/mpsc"
+	"github.com/pojol/braid/router/msg"
+)
+
+// mailbox is the user message queue of an actor, optionally bounded by a capacity
+//
+//	Block / Reject / DropNewest reserve a slot before pushing, the slot is released when the message is popped
+//	DropOldest always accepts the message, the consumer discards the oldest messages while the queue is over capacity
+type mailbox struct {
+	*mpsc.Queue
+
+	capacity int
+	policy   core.MailboxOverflowPolicy
+	slots    chan struct{}
+
+	dropped uint64
+	onDrop  func(mw *msg.Wrapper, reason error)
+}
+
+func newMailbox(capacity int, policy core.MailboxOverflowPolicy) *mailbox {
+	mb := &mailbox{
+		Queue:    mpsc.New(),
+		capacity: capacity,
+		policy:   policy,
+	}
+
+	if capacity > 0 && policy != core.MailboxDropOldest {
+		mb.slots = make(chan struct{}, capacity)
+	}
+
+	return mb
+}
+
+// acquire reserves room for the message according to the overflow policy
+//
+//	returns false if the message must not be pushed, err is ErrMailboxFull if the sender has to be told
+//	(a message discarded by DropNewest is not an error for the sender)
+func (mb *mailbox) acquire(mw *msg.Wrapper) (bool, error) {
+	if mb.slots == nil {
+		return true, nil
+	}
+
+	switch mb.policy {
+	case core.MailboxBlock:
+		select {
+		case mb.slots <- struct{}{}:
+		case <-mw.Ctx.Done():
+			err := fmt.Errorf("%w: blocked until %v", core.ErrMailboxFull, mw.Ctx.Err())
+			mb.drop(mw, err)
+			return false, err
+		}
+	case core.MailboxReject:
+		select {
+		case mb.slots <- struct{}{}:
+		default:
+			err := fmt.Errorf("%w: capacity %v", core.ErrMailboxFull, mb.capacity)
+			mb.drop(mw, err)
+			return false, err
+		}
+	case core.MailboxDropNewest:
+		select {
+		case mb.slots <- struct{}{}:
+		default:
+			mb.drop(mw, fmt.Errorf("%w: newest message dropped", core.ErrMailboxFull))
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// release gives back a slot reserved by acquire which was not used
+func (mb *mailbox) release() {
+	if mb.slots != nil {
+		select {
+		case <-mb.slots:
+		default:
+		}
+	}
+}
+
+// push puts the message into the mailbox according to the overflow policy
+func (mb *mailbox) push(mw *msg.Wrapper) error {
+	ok, err := mb.acquire(mw)
+	if ok {
+		mb.Push(mw)
+	}
+	return err
+}
+
+// Pop removes the item from the front of the mailbox and releases its slot
+//
+// Pop must be called from a single, consumer goroutine
+func (mb *mailbox) Pop() interface{} {
+	if mb.policy == core.MailboxDropOldest && mb.capacity > 0 {
+		for int(mb.Count()) > mb.capacity {
+			if mw, ok := mb.Queue.Pop().(*msg.Wrapper); ok {
+				mb.drop(mw, fmt.Errorf("%w: oldest message dropped", core.ErrMailboxFull))
+			}
+		}
+	}
+
+	v := mb.Queue.Pop()
+	if v != nil {
+		mb.release()
+	}
+
+	return v
+}
+
+// handOver moves the pending messages to the mailbox of a fresh instance, in order
+//
+//	the slot of each message is released here, which wakes the senders blocked on this mailbox (they are
+//	forwarded to the fresh instance), and reserved in the fresh mailbox. It must be called once no message
+//	can be pushed to either mailbox, the fresh mailbox is then empty and has room for all of them
+func (mb *mailbox) handOver(to *mailbox) {
+	for !mb.Empty() {
+		v := mb.Queue.Pop()
+		mb.release()
+
+		if to.slots != nil {
+			select {
+			case to.slots <- struct{}{}:
+			default:
+			}
+		}
+		to.Push(v)
+	}
+}
+
+func (mb *mailbox) drop(mw *msg.Wrapper, reason error) {
+	atomic.AddUint64(&mb.dropped, 1)
+	if mb.onDrop != nil {
+		mb.onDrop(mw, reason)
+	}
+}
+
+// Dropped returns the number of messages dropped or rejected by the overflow policy
+func (mb *mailbox) Dropped() uint64 {
+	return atomic.LoadUint64(&mb.dropped)
+}
@@ -0,0 +1,78 @@
+package actor
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pojol/braid/lib/log"
+)
+
+// OnPassivate registers a hook that runs on the actor goroutine before an idle actor is passivated
+//
+//	returning an error (or panicking) keeps the actor alive until the next idle check
+func (a *Runtime) OnPassivate(f func() error) {
+	a.passivateHook = f
+}
+
+// checkIdle passivates the actor if it has been idle for the configured timeout, it runs on the actor goroutine
+//
+//	returns the duration until the next idle check
+func (a *Runtime) checkIdle() time.Duration {
+	idle := time.Since(a.lastActive)
+	if idle < a.idleTimeout {
+		return a.idleTimeout - idle
+	}
+
+	if err := a.passivate(); err != nil {
+		log.WarnF("[braid.actor] %v passivate aborted err %v", a.Id, err)
+	}
+
+	return a.idleTimeout
+}
+
+// passivate runs the OnPassivate hook and unregisters the actor from the system
+//
+//	from the moment passivation starts, Received rejects new messages with core.ErrActorPassivated,
+//	messages which were already accepted are still handled before the actor exits
+func (a *Runtime) passivate() (err error) {
+	if atomic.LoadInt32(&a.closed) != 0 {
+		return nil
+	}
+	if !atomic.CompareAndSwapInt32(&a.passivating, 0, 1) {
+		return nil
+	}
+
+	// a message slipped in after the idle check, the actor is no longer idle
+	if !a.q.Empty() || !a.urgentQueue.Empty() || !a.reenterQueue.Empty() || !a.unstashQueue.Empty() || len(a.stash) > 0 {
+		atomic.StoreInt32(&a.passivating, 0)
+		return nil
+	}
+
+	if a.passivateHook != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("on passivate panic: %v", r)
+				}
+			}()
+			err = a.passivateHook()
+		}()
+
+		if err != nil {
+			a.lastActive = time.Now()
+			atomic.StoreInt32(&a.passivating, 0)
+			return err
+		}
+	}
+
+	log.InfoF("[braid.actor] %v passivated after %v idle", a.Id, a.idleTimeout)
+
+	go func() {
+		if err := a.Sys.Unregister(a.Id, a.Ty); err != nil {
+			log.WarnF("[braid.actor] %v passivate unregister err %v", a.Id, err)
+		}
+	}()
+
+	return nil
+}
@@ -1,17 +1,28 @@
 package actor
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/lib/mpsc"
 	"github.com/pojol/braid/router/msg"
 )
 
 // Future represents an asynchronous operation
+//
+//	futures created by ReenterCall run their continuations on the owning actor's goroutine through the reenter queue,
+//	futures created with NewFuture run them on a new goroutine
 type Future struct {
 	result    *msg.Wrapper
 	done      chan struct{}
-	callbacks []func(mw *msg.Wrapper)
+	callbacks []func(mw *msg.Wrapper) // run by the goroutine completing the future
+	queue     *mpsc.Queue             // reenter queue of the owning actor
+	cancel    func()                  // releases the pending operation on Cancel
 	mutex     sync.Mutex
 }
 
@@ -21,39 +32,175 @@ func NewFuture() *Future {
 	}
 }
 
-func (f *Future) Then(callback func(mw *msg.Wrapper)) core.IFuture {
-	f.mutex.Lock()
-	defer f.mutex.Unlock()
+func newReenterFuture(queue *mpsc.Queue) *Future {
+	f := NewFuture()
+	f.queue = queue
+	return f
+}
+
+// derive creates a future owned by the same actor
+func (f *Future) derive() *Future {
+	return newReenterFuture(f.queue)
+}
 
+// listen calls back with the result once the future is completed, on the completing goroutine
+func (f *Future) listen(callback func(mw *msg.Wrapper)) {
+	f.mutex.Lock()
 	if f.IsCompleted() {
-		go callback(f.result)
-		return NewFuture()
+		f.mutex.Unlock()
+		callback(f.result)
+		return
+	}
+	f.callbacks = append(f.callbacks, callback)
+	f.mutex.Unlock()
+}
+
+func listen(f core.IFuture, callback func(mw *msg.Wrapper)) {
+	if ff, ok := f.(*Future); ok {
+		ff.listen(callback)
+		return
 	}
+	f.Then(callback)
+}
+
+func (f *Future) Then(callback func(mw *msg.Wrapper)) core.IFuture {
+	next := f.derive()
+	next.cancel = func() { f.Cancel() }
+
+	f.listen(func(mw *msg.Wrapper) {
+		f.dispatch(mw, callback, next)
+	})
+
+	return next
+}
+
+// dispatch runs the continuation on the owning actor's goroutine, then completes the next future
+func (f *Future) dispatch(result *msg.Wrapper, callback func(mw *msg.Wrapper), next *Future) {
+	run := func(mw *msg.Wrapper) error {
+		defer func() {
+			if r := recover(); r != nil {
+				log.ErrorF("panic in future continuation: %v", r)
+				next.Complete(futureErr(mw, fmt.Errorf("panic in future continuation: %v", r)))
+			}
+		}()
 
-	newFuture := NewFuture()
-	f.callbacks = append(f.callbacks, func(mw *msg.Wrapper) {
 		callback(mw)
-		newFuture.Complete(mw)
+		next.Complete(mw)
+		return nil
+	}
+
+	if f.queue != nil {
+		f.queue.Push(&reenterMessage{action: run, msg: result})
+		return
+	}
+	go run(result)
+}
+
+func (f *Future) All(others ...core.IFuture) core.IFuture {
+	futures := append([]core.IFuture{f}, others...)
+
+	next := f.derive()
+	next.cancel = func() {
+		for _, src := range futures {
+			src.Cancel()
+		}
+	}
+
+	var mu sync.Mutex
+	remaining := len(futures)
+	results := make([]*msg.Wrapper, len(futures))
+
+	for i, src := range futures {
+		idx := i
+		listen(src, func(mw *msg.Wrapper) {
+			mu.Lock()
+			results[idx] = mw
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+
+			if last {
+				next.Complete(joinResults(results))
+			}
+		})
+	}
+
+	return next
+}
+
+func (f *Future) Any(others ...core.IFuture) core.IFuture {
+	futures := append([]core.IFuture{f}, others...)
+
+	next := f.derive()
+	next.cancel = func() {
+		for _, src := range futures {
+			src.Cancel()
+		}
+	}
+
+	for _, src := range futures {
+		listen(src, next.Complete)
+	}
+
+	return next
+}
+
+func (f *Future) WithTimeout(timeout time.Duration) core.IFuture {
+	next := f.derive()
+	next.cancel = func() { f.Cancel() }
+
+	timer := time.AfterFunc(timeout, func() {
+		if next.complete(futureErr(nil, core.ErrFutureTimeout)) {
+			f.Cancel()
+		}
 	})
 
-	return newFuture
+	f.listen(func(mw *msg.Wrapper) {
+		timer.Stop()
+		next.Complete(mw)
+	})
+
+	return next
 }
 
-func (f *Future) Complete(result *msg.Wrapper) {
+func (f *Future) Cancel() bool {
 	f.mutex.Lock()
-	defer f.mutex.Unlock()
+	cancel := f.cancel
+	f.mutex.Unlock()
+
+	if !f.complete(futureErr(nil, core.ErrFutureCanceled)) {
+		return false
+	}
 
+	if cancel != nil {
+		cancel()
+	}
+	return true
+}
+
+func (f *Future) Complete(result *msg.Wrapper) {
+	f.complete(result)
+}
+
+// complete returns false if the future was already completed
+func (f *Future) complete(result *msg.Wrapper) bool {
+	f.mutex.Lock()
 	if f.IsCompleted() {
-		return // 已经完成
+		f.mutex.Unlock()
+		return false // 已经完成
 	}
 
 	f.result = result
 	close(f.done)
 
-	for _, callback := range f.callbacks {
-		go callback(f.result)
-	}
+	callbacks := f.callbacks
 	f.callbacks = nil
+	f.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(result)
+	}
+	return true
 }
 
 func (f *Future) IsCompleted() bool {
@@ -65,6 +212,33 @@ func (f *Future) IsCompleted() bool {
 	}
 }
 
+func futureErr(mw *msg.Wrapper, err error) *msg.Wrapper {
+	ctx := context.Background()
+	if mw != nil && mw.Ctx != nil {
+		ctx = mw.Ctx
+	}
+	return &msg.Wrapper{Ctx: ctx, Err: err}
+}
+
+// joinResults merges the results of All, the responses stay on the wrappers passed to each ReenterCall
+func joinResults(results []*msg.Wrapper) *msg.Wrapper {
+	var errs []error
+	var base *msg.Wrapper
+	for _, mw := range results {
+		if mw == nil {
+			continue
+		}
+		if base == nil {
+			base = mw
+		}
+		if mw.Err != nil {
+			errs = append(errs, mw.Err)
+		}
+	}
+
+	return futureErr(base, errors.Join(errs...))
+}
+
 type reenterMessage struct {
 	action EventHandler
 	msg    interface{}
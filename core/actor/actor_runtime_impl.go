@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -26,17 +27,52 @@ type Runtime struct {
 	Id           string
 	Ty           string
 	Sys          core.ISystem
-	q            *mpsc.Queue
+	q            *mailbox
+	urgentQueue  *mpsc.Queue // high priority requests, served before the other lanes
 	reenterQueue *mpsc.Queue
 	closed       int32
+	restarting   int32
 	closeCh      chan struct{}
 	shutdownCh   chan struct{}
 	chains       map[string]core.IChain
 	recovery     RecoveryFunc
 
-	timers    map[core.ITimer]struct{}
-	timerChan chan core.ITimer
-	//timerWg   sync.WaitGroup // 用于等待所有 timer goroutine 退出
+	behaviors    []map[string]core.IChain // the chains replaced by Become
+	current      *msg.Wrapper             // the message being handled
+	stashed      bool                     // the current message was stashed, its sender keeps waiting
+	stash        []*msg.Wrapper
+	unstashQueue *mpsc.Queue
+
+	builder    core.IActorBuilder
+	initCtx    context.Context
+	supervisor *core.SupervisorStrategy
+	restarts   []time.Time
+
+	idleTimeout   time.Duration
+	lastActive    time.Time
+	passivating   int32
+	passivateHook func() error
+
+	startHook   func() error
+	stopHook    func() error
+	restartHook func(core.Failure) error
+	startCh     chan startRequest
+	stoppedCh   chan struct{} // closed once the update loop has returned
+
+	// forward is set once the actor has been restarted, messages are then delivered to the fresh instance
+	forwardMu sync.RWMutex
+	forward   *Runtime
+
+	timers     map[core.ITimer]struct{}
+	timerQueue *mpsc.Queue
+
+	// set when the actor type selects a dispatcher, the actor then runs in turns on the dispatcher workers
+	// instead of its update goroutine, ctrlQueue carries the start, shutdown and idle signals
+	dispatcher core.IDispatcher
+	ctrlQueue  *mpsc.Queue
+	scheduled  int32
+	phase      dispatchPhase
+	idle       *time.Timer
 
 	actorCtx *actorContext
 }
@@ -50,13 +86,30 @@ func (a *Runtime) ID() string {
 }
 
 func (a *Runtime) Init(ctx context.Context) {
-	a.q = mpsc.New()
+	a.initCtx = ctx
+	if builder, ok := ctx.Value(core.ActorBuilderKey{}).(core.IActorBuilder); ok {
+		a.builder = builder
+		a.supervisor = builder.GetSupervisor()
+		a.idleTimeout = builder.GetIdleTimeout()
+		a.dispatcher = builder.GetDispatcher()
+		a.q = newMailbox(builder.GetMailboxCapacity(), builder.GetMailboxOverflow())
+	} else {
+		a.q = newMailbox(0, core.MailboxBlock)
+	}
+	a.q.onDrop = a.onMailboxDrop
+	a.urgentQueue = mpsc.New()
 	a.reenterQueue = mpsc.New()
+	a.unstashQueue = mpsc.New()
 	atomic.StoreInt32(&a.closed, 0) // 初始化closed状态为0（未关闭）
 	a.closeCh = make(chan struct{})
 	a.shutdownCh = make(chan struct{})
+	a.startCh = make(chan startRequest)
+	a.stoppedCh = make(chan struct{})
 	a.chains = make(map[string]core.IChain)
 	a.recovery = defaultRecovery
+	if a.supervisor != nil && a.supervisor.Recovery != nil {
+		a.recovery = a.supervisor.Recovery
+	}
 	a.actorCtx = &actorContext{
 		ctx: ctx,
 	}
@@ -65,7 +118,16 @@ func (a *Runtime) Init(ctx context.Context) {
 	a.actorCtx.ctx = context.WithValue(a.actorCtx.ctx, actorKey{}, a)
 
 	a.timers = make(map[core.ITimer]struct{})
-	a.timerChan = make(chan core.ITimer, 1024)
+	a.timerQueue = mpsc.New()
+	a.lastActive = time.Now()
+
+	if a.dispatcher != nil {
+		a.ctrlQueue = mpsc.New()
+		for _, q := range []*mpsc.Queue{a.q.Queue, a.urgentQueue, a.reenterQueue, a.unstashQueue, a.timerQueue, a.ctrlQueue} {
+			q.Notify(a.schedule)
+		}
+		return
+	}
 
 	go a.update()
 }
@@ -74,6 +136,20 @@ func defaultRecovery(r interface{}) {
 	log.WarnF("[braid.actor] Recovered from panic: %v\nStack trace:\n%s\n", r, debug.Stack())
 }
 
+func (a *Runtime) onMailboxDrop(mw *msg.Wrapper, reason error) {
+	a.Sys.DeadLetter(mw, core.DeadLetterMailboxOverflow, reason)
+
+	if mw.Err == nil {
+		mw.Err = reason
+	}
+	mw.Reply()
+}
+
+// MailboxDropped returns the number of messages dropped or rejected by the mailbox overflow policy
+func (a *Runtime) MailboxDropped() uint64 {
+	return a.q.Dropped()
+}
+
 func (a *Runtime) Context() core.ActorContext {
 	return a.actorCtx
 }
@@ -98,29 +174,19 @@ func (a *Runtime) OnTimer(dueTime int64, interval int64, f func(interface{}) err
 		time.Duration(interval)*time.Millisecond,
 		f, args)
 
-	a.timers[info] = struct{}{}
-	//a.timerWg.Add(1)
-
-	go func() {
-		//defer a.timerWg.Done()
+	info.wheel = a.Sys.TimingWheel()
+	info.queue = a.timerQueue
 
-		// 如果 dueTime 大于 0，使用 dueTime 进行第一次触发
-		if info.dueTime > 0 {
-			<-time.After(info.dueTime)
-			a.timerChan <- info
-		}
+	a.timers[info] = struct{}{}
 
-		info.ticker = time.NewTicker(info.interval)
+	// a periodic timer without due time first fires after one interval
+	due := info.dueTime
+	if due == 0 {
+		due = info.interval
+	}
 
-		for {
-			select {
-			case <-info.ticker.C:
-				a.timerChan <- info
-			case <-a.shutdownCh:
-				return
-			}
-		}
-	}()
+	// expirations are delivered by the node timing wheel into the timer queue, the callback runs on the actor goroutine
+	info.schedule(due)
 
 	return info
 }
@@ -149,14 +215,14 @@ func (a *Runtime) Sub(topic string, channel string, callback func(ctx core.Actor
 		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
 	}
 
-	ch.Arrived(a.q)
+	ch.Arrived(a.q.Queue)
 
 	a.OnEvent(channel, callback)
 
 	return nil
 }
 
-func (a *Runtime) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error {
+func (a *Runtime) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error {
 
 	if mw.Req.Header.OrgActorID == "" { // Only record the original sender
 		mw.Req.Header.OrgActorID = a.Id
@@ -166,48 +232,113 @@ func (a *Runtime) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) err
 	// Updated to the latest value on each call
 	mw.Req.Header.PrevActorType = a.Ty
 
-	return a.Sys.Call(idOrSymbol, actorType, event, mw)
+	// the actor is blocked until the call returns, as are the synchronous callers of the message it handles
+	var path []string
+	if a.current != nil {
+		path = a.current.Req.Header.CallPath
+	}
+	mw.Req.Header.CallPath = append(append([]string{}, path...), a.Id)
+
+	// a call to an actor of the path waits on itself, it fails instead of waiting for the call timeout
+	// (a direct self call is still rejected by Received with ErrSelfCall)
+	if idOrSymbol != a.Id {
+		if err := core.CallCycle(mw.Req.Header.CallPath, idOrSymbol); err != nil {
+			return err
+		}
+	}
+
+	return a.Sys.Call(idOrSymbol, actorType, event, mw, opts...)
 }
 
 func (a *Runtime) Received(mw *msg.Wrapper) error {
 
-	if mw.Req.Header.OrgActorID != "" {
-		if mw.Req.Header.OrgActorID == a.Id {
-			return node.ErrSelfCall
+	a.forwardMu.RLock()
+	if fwd := a.forward; fwd != nil {
+		a.forwardMu.RUnlock()
+		return fwd.Received(mw)
+	}
+	a.forwardMu.RUnlock()
+
+	// only a synchronous call waits on its target, a sent message back to one of its callers is delivered
+	if mw.Replied() != nil {
+		if mw.Req.Header.OrgActorID != "" {
+			if mw.Req.Header.OrgActorID == a.Id {
+				return node.ErrSelfCall
+			}
+		}
+
+		// a call routed by a symbol is only known to close a cycle once it reached its target
+		if err := core.CallCycle(mw.Req.Header.CallPath, a.Id); err != nil {
+			return err
 		}
 	}
 
-	if atomic.LoadInt32(&a.closed) != 0 {
+	if atomic.LoadInt32(&a.passivating) != 0 {
+		return fmt.Errorf("actor %v %w", a.Id, core.ErrActorPassivated)
+	}
+
+	// while a restart waits for its backoff the messages are buffered in this mailbox, under its capacity and
+	// overflow policy, and handed over to the fresh instance once it is built (see restart)
+	if atomic.LoadInt32(&a.closed) != 0 && atomic.LoadInt32(&a.restarting) == 0 {
 		// Actor已关闭，不处理消息，也不增加计数器
-		log.WarnF("actor %v is closed, message %v will be ignored", a.Id, mw.Req.Header.Event)
-		return fmt.Errorf("actor %v is closed", a.Id)
+		err := fmt.Errorf("actor %v is closed", a.Id)
+		a.Sys.DeadLetter(mw, core.DeadLetterActorClosed, err)
+		return err
 	}
 
-	mw.GetWg().Add(1)
-	a.q.Push(mw)
+	// high priority requests take their own lane, they are not bound by the capacity of the mailbox
+	urgent := mw.Req.Header.Priority >= msg.PriorityHigh
+	if !urgent {
+		// on failure the mailbox has already answered the message through onMailboxDrop
+		ok, err := a.q.acquire(mw)
+		if !ok {
+			return err
+		}
+	}
+
+	a.forwardMu.RLock()
+	if fwd := a.forward; fwd != nil {
+		// restarted while waiting for room in the mailbox
+		a.forwardMu.RUnlock()
+		if !urgent {
+			a.q.release()
+		}
+		return fwd.Received(mw)
+	}
+	if urgent {
+		a.urgentQueue.Push(mw)
+	} else {
+		a.q.Push(mw)
+	}
+	a.forwardMu.RUnlock()
 
 	return nil
 }
 
-func (a *Runtime) ReenterCall(idOrSymbol, actorType, event string, rmw *msg.Wrapper) core.IFuture {
+func (a *Runtime) ReenterCall(idOrSymbol, actorType, event string, rmw *msg.Wrapper, opts ...core.CallOption) core.IFuture {
 	if rmw.Req.Header.OrgActorID == "" {
 		rmw.Req.Header.OrgActorID = a.Id
 		rmw.Req.Header.OrgActorType = a.Ty
 	}
 	rmw.Req.Header.PrevActorType = a.Ty
 
-	reenterFuture := NewFuture()
-	callFuture := NewFuture()
+	reenterFuture := newReenterFuture(a.reenterQueue)
 
-	deadline, ok := rmw.Ctx.Deadline()
-	var timeout time.Duration
-	if ok {
+	// the call is bounded by the timeout of its options (30s by default), and by the deadline of its context
+	timeout := 30 * time.Second
+	if p := core.BuildCallParm(opts...); p.Timeout > 0 {
+		timeout = p.Timeout
+	}
+	if deadline, ok := rmw.Ctx.Deadline(); ok && time.Until(deadline) < timeout {
 		timeout = time.Until(deadline)
-	} else {
-		timeout = 30 * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	swappedWrapper := msg.Swap(rmw)
+
+	// cancelling the future abandons the pending call
+	var cancel context.CancelFunc
+	swappedWrapper.Ctx, cancel = context.WithTimeout(swappedWrapper.Ctx, timeout)
+	reenterFuture.cancel = cancel
 
 	go func() {
 		select {
@@ -221,7 +352,7 @@ func (a *Runtime) ReenterCall(idOrSymbol, actorType, event string, rmw *msg.Wrap
 			}
 
 			reenterFuture.Complete(errWrapper)
-		case <-callFuture.done:
+		case <-reenterFuture.done:
 		}
 	}()
 
@@ -229,50 +360,26 @@ func (a *Runtime) ReenterCall(idOrSymbol, actorType, event string, rmw *msg.Wrap
 		defer cancel()
 		log.InfoF("[ReenterCall] Starting call to %s.%s", actorType, event)
 
-		swappedWrapper := msg.Swap(rmw)
-		//swappedWrapper.Ctx = ctx
+		err := a.Sys.Call(idOrSymbol, actorType, event, swappedWrapper, opts...)
 
-		err := a.Sys.Call(idOrSymbol, actorType, event, swappedWrapper)
-		if err != nil {
-			callFuture.Complete(&msg.Wrapper{
-				Ctx: ctx,
-				Err: err,
-			})
-			return
-		}
-
-		callFuture.Complete(swappedWrapper)
-	}()
-
-	// 设置回调，将处理放入重入队列
-	callFuture.Then(func(ret *msg.Wrapper) {
-
-		reenterMsg := &reenterMessage{
+		// 将结果放入重入队列，在actor的goroutine中写回
+		a.reenterQueue.Push(&reenterMessage{
 			action: func(mw *msg.Wrapper) error {
-
-				defer func() {
-					if r := recover(); r != nil {
-						log.ErrorF("panic in ReenterCall: %v", r)
-						rmw.Err = fmt.Errorf("panic in ReenterCall: %v", r)
-						reenterFuture.Complete(rmw)
-					}
-				}()
-
-				if mw.Err != nil {
-					rmw.Err = mw.Err
-					reenterFuture.Complete(rmw)
-					return mw.Err
+				if reenterFuture.IsCompleted() {
+					return nil // cancelled or timed out
 				}
 
-				rmw.Res = mw.Res
+				if err != nil {
+					rmw.Err = err
+				} else {
+					rmw.Res = mw.Res
+				}
 				reenterFuture.Complete(rmw)
-				return nil
+				return err
 			},
-			msg: ret,
-		}
-
-		a.reenterQueue.Push(reenterMsg)
-	})
+			msg: swappedWrapper,
+		})
+	}()
 
 	return reenterFuture
 }
@@ -283,7 +390,7 @@ func (a *Runtime) update() {
 		ticker := time.NewTicker(10 * time.Millisecond)
 		defer ticker.Stop()
 
-		for !a.q.Empty() || !a.reenterQueue.Empty() {
+		for !a.q.Empty() || !a.urgentQueue.Empty() || !a.reenterQueue.Empty() || !a.unstashQueue.Empty() {
 			select {
 			case <-timeout:
 				log.WarnF("[braid.actor] %s force close due to timeout waiting for queue to empty remaining %v", a.Id, a.q.Count())
@@ -300,78 +407,198 @@ func (a *Runtime) update() {
 		}
 	}
 
-	for {
-		select {
-		case timerInfo := <-a.timerChan:
-			if atomic.LoadInt32(&a.closed) != 0 {
-				continue
-			}
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						a.recovery(r)
-					}
-				}()
-
-				if err := timerInfo.Execute(); err != nil {
-					log.WarnF("actor %v timer callback error: %v", a.Id, err)
-				}
-			}()
-		case <-a.q.C:
-			msgInterface := a.q.Pop()
+	defer close(a.stoppedCh)
 
-			mw, ok := msgInterface.(*msg.Wrapper)
-			if !ok {
-				log.WarnF("actor %v received non-Message type %v", a.Id, reflect.TypeOf(msgInterface))
-				continue
-			}
+	if !a.awaitStart() {
+		atomic.StoreInt32(&a.closed, 2)
+		a.abandon()
+		return
+	}
 
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						a.recovery(r)
-					}
+	// idle is only armed for actors with an idle timeout, a nil channel never fires
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if a.idleTimeout > 0 {
+		idle = time.NewTimer(a.idleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
 
-					mw.GetWg().Done()
-				}()
+	// the shutdown channel stays readable once closed, it is no longer selected after the close started
+	shutdownC := a.shutdownCh
 
-				if chain, ok := a.chains[mw.Req.Header.Event]; ok {
-					err := chain.Execute(mw)
-					if err != nil {
-						log.WarnF("actor %v event %v execute err %v", a.Id, mw.Req.Header.Event, err)
-					}
-				} else {
-					log.WarnF("actor %v No handlers for message type: %s", a.Id, mw.Req.Header.Event)
-				}
-			}()
+	// whichever lane wakes the loop, the item handled is picked by next in priority order.
+	// wake keeps the loop going while a lane whose signal was consumed by the wakeup still has items
+	wake := make(chan struct{}, 1)
 
+	for {
+		select {
+		case <-a.urgentQueue.C:
 		case <-a.reenterQueue.C:
-			reenterMsgInterface := a.reenterQueue.Pop()
-			if reenterMsg, ok := reenterMsgInterface.(*reenterMessage); ok {
-				reenterMsg.action(reenterMsg.msg.(*msg.Wrapper))
-			}
+		case <-a.timerQueue.C:
+		case <-a.q.C:
+		case <-wake:
 
-		case <-a.shutdownCh:
+		case <-idleC:
+			idle.Reset(a.checkIdle())
+			continue
+		case <-shutdownC:
+			shutdownC = nil
 			if atomic.CompareAndSwapInt32(&a.closed, 0, 1) {
 				log.DebugF("[braid.actor] %s exiting check close %v", a.Id, atomic.LoadInt32(&a.closed))
 				go checkClose()
 			}
+			continue
 		case <-a.closeCh:
 			log.DebugF("[braid.actor] %s exiting closed", a.Id)
+			if atomic.LoadInt32(&a.restarting) == 0 {
+				a.runHook("stop", a.stopHook)
+				a.dropStash()
+			}
 			return
 		}
+
+		if _, restarted := a.next(); restarted {
+			return
+		}
+		if a.pending() {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
 	}
 }
 
+// next handles one item of the actor, the lanes are served in priority order:
+// high priority requests, reentry callbacks, timer expirations, unstashed messages, then the user messages
+//
+//	served is false if all the lanes are empty, restarted is true if the actor stops processing its mailbox
+func (a *Runtime) next() (served bool, restarted bool) {
+	if v := a.urgentQueue.Pop(); v != nil {
+		return true, a.handleMessage(v)
+	}
+	if v := a.reenterQueue.Pop(); v != nil {
+		a.handleReenter(v)
+		return true, false
+	}
+	if v := a.timerQueue.Pop(); v != nil {
+		exp, ok := v.(*timerExpiration)
+		return true, ok && a.handleTimer(exp)
+	}
+	if v := a.unstashQueue.Pop(); v != nil {
+		return true, a.handleMessage(v)
+	}
+	if v := a.q.Pop(); v != nil {
+		return true, a.handleMessage(v)
+	}
+	return false, false
+}
+
+// pending returns true if one of the lanes has an item, it is called by the consumer of the lanes
+func (a *Runtime) pending() bool {
+	return !a.urgentQueue.Empty() || !a.reenterQueue.Empty() || !a.timerQueue.Empty() || !a.unstashQueue.Empty() || !a.q.Empty()
+}
+
+// handleTimer runs the callback of a timer expiration
+//
+//	returns true if the actor stops processing its mailbox (it is being restarted)
+func (a *Runtime) handleTimer(exp *timerExpiration) bool {
+	if !exp.valid() || atomic.LoadInt32(&a.closed) != 0 {
+		return false
+	}
+	timerInfo := exp.info
+	if timerInfo.cron != nil && !a.cronDue(timerInfo) {
+		return false
+	}
+	var failure *core.Failure
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				failure = a.fail(r)
+			}
+		}()
+
+		if err := timerInfo.Execute(); err != nil {
+			log.WarnF("actor %v timer callback error: %v", a.Id, err)
+		}
+	}()
+	a.lastActive = time.Now()
+
+	// cron timers are armed for their next fire, one-shot timers are done once they fired
+	if timerInfo.cron != nil && exp.valid() {
+		timerInfo.scheduleCron(time.Now())
+	} else if timerInfo.Interval() == 0 && exp.valid() {
+		timerInfo.active.Store(false)
+		delete(a.timers, timerInfo)
+	}
+
+	return failure != nil && a.supervise(*failure)
+}
+
+// handleMessage runs the chain of the event of a message
+//
+//	returns true if the actor stops processing its mailbox (it is being restarted)
+func (a *Runtime) handleMessage(msgInterface interface{}) bool {
+	mw, ok := msgInterface.(*msg.Wrapper)
+	if !ok {
+		log.WarnF("actor %v received non-Message type %v", a.Id, reflect.TypeOf(msgInterface))
+		return false
+	}
+
+	a.current, a.stashed = mw, false
+
+	var failure *core.Failure
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				failure = a.fail(r)
+			}
+
+			// a stashed message is answered once it is handled again
+			if !a.stashed && !mw.ReplyDeferred() {
+				mw.Reply()
+			}
+			a.current = nil
+		}()
+
+		if deadline := mw.Req.Header.Deadline; deadline != 0 && time.Now().UnixNano() > deadline {
+			// the caller already gave up on the message, the work is skipped
+			a.Sys.DeadLetter(mw, core.DeadLetterExpired, fmt.Errorf("actor %v event %v deadline exceeded", a.Id, mw.Req.Header.Event))
+		} else if chain, ok := a.chains[mw.Req.Header.Event]; ok {
+			err := chain.Execute(mw)
+			if err != nil {
+				a.Sys.DeadLetter(mw, core.DeadLetterHandlerError, err)
+			}
+		} else {
+			a.Sys.DeadLetter(mw, core.DeadLetterUnknownEvent, fmt.Errorf("actor %v no handlers for event %v", a.Id, mw.Req.Header.Event))
+		}
+	}()
+	a.lastActive = time.Now()
+
+	return failure != nil && a.supervise(*failure)
+}
+
+// handleReenter runs a reentry callback (the result of a ReenterCall or a future continuation)
+func (a *Runtime) handleReenter(reenterMsgInterface interface{}) {
+	if reenterMsg, ok := reenterMsgInterface.(*reenterMessage); ok {
+		reenterMsg.action(reenterMsg.msg.(*msg.Wrapper))
+	}
+	a.lastActive = time.Now()
+}
+
 func (a *Runtime) Exit() {
 	log.DebugF("[braid.actor] %s exiting state %v remaining msg %v", a.Id, atomic.LoadInt32(&a.closed), a.q.Count())
+	if a.dispatcher != nil {
+		a.ctrlQueue.Push(shutdownSignal{})
+	}
 	close(a.shutdownCh) // 发送关闭信号
 	<-a.closeCh         // 等待所有消息处理完毕
+	<-a.stoppedCh       // wait for the OnStop hook
 
 	for t := range a.timers {
 		a.CancelTimer(t)
 	}
-	//a.timerWg.Wait()
 
 	log.InfoF("[braid.actor] %s has exited", a.Id)
 }
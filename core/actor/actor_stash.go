@@ -0,0 +1,75 @@
+package actor
+
+import (
+	"fmt"
+
+	"github.com/pojol/braid/core"
+)
+
+// Stash defers the message being handled, it is handled again once UnstashAll is called
+//
+//	the sender of a stashed Call keeps waiting until the message is handled.
+//	Stash must be called from a handler of the actor, it returns core.ErrNothingToStash from timers and callbacks
+func (a *Runtime) Stash() error {
+	if a.current == nil {
+		return fmt.Errorf("actor %v %w", a.Id, core.ErrNothingToStash)
+	}
+	if a.stashed {
+		return nil
+	}
+
+	a.stashed = true
+	a.stash = append(a.stash, a.current)
+	return nil
+}
+
+// UnstashAll requeues the stashed messages in their original order, they are handled before the messages
+// which are waiting in the mailbox
+func (a *Runtime) UnstashAll() {
+	for _, mw := range a.stash {
+		a.unstashQueue.Push(mw)
+	}
+	a.stash = nil
+}
+
+// Become replaces the event chains of the actor, the chains registered with OnEvent are restored by Unbecome
+//
+//	the whole map is swapped, an event which has no chain in the new behavior is dead-lettered as unknown
+func (a *Runtime) Become(chains map[string]core.IChain) {
+	a.behaviors = append(a.behaviors, a.chains)
+	a.chains = chains
+}
+
+// Unbecome restores the event chains which were replaced by the last Become
+func (a *Runtime) Unbecome() {
+	if len(a.behaviors) == 0 {
+		return
+	}
+
+	a.chains = a.behaviors[len(a.behaviors)-1]
+	a.behaviors = a.behaviors[:len(a.behaviors)-1]
+}
+
+// dropStash dead-letters the messages which are still stashed when the actor stops
+func (a *Runtime) dropStash() {
+	err := fmt.Errorf("actor %v stopped with stashed message", a.Id)
+	for _, mw := range a.stash {
+		a.Sys.DeadLetter(mw, core.DeadLetterActorClosed, err)
+		if mw.Err == nil {
+			mw.Err = err
+		}
+		mw.Reply()
+	}
+	a.stash = nil
+}
+
+// handOverStash gives the stashed messages to the instance which replaces the actor on restart
+func (a *Runtime) handOverStash(rt *Runtime) {
+	for !a.unstashQueue.Empty() {
+		rt.unstashQueue.Push(a.unstashQueue.Pop())
+	}
+	for _, mw := range a.stash {
+		rt.unstashQueue.Push(mw)
+	}
+	a.stash = nil
+}
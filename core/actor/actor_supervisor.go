@@ -0,0 +1,203 @@
+package actor
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+)
+
+// runtimeHolder is implemented by every actor that embeds *Runtime
+type runtimeHolder interface {
+	runtime() *Runtime
+}
+
+func (a *Runtime) runtime() *Runtime {
+	return a
+}
+
+// fail builds the failure of a recovered panic and passes it to the recovery function
+func (a *Runtime) fail(r interface{}) *core.Failure {
+	a.recovery(r)
+
+	return &core.Failure{
+		ActorID: a.Id,
+		ActorTy: a.Ty,
+		Reason:  r,
+		Stack:   debug.Stack(),
+	}
+}
+
+// supervise applies the supervisor directive to a failure, it runs on the actor goroutine
+//
+//	returns true if the actor stops processing its mailbox (it is being restarted)
+func (a *Runtime) supervise(f core.Failure) bool {
+	strategy := a.supervisor
+	if strategy == nil {
+		if a.parent() == "" {
+			return false
+		}
+		// a child without its own strategy is supervised by its parent
+		strategy = &core.SupervisorStrategy{Directive: core.SupervisorEscalate}
+	}
+
+	directive := strategy.Decide(f)
+	if directive == core.SupervisorEscalate {
+		directive, strategy = a.escalate(f, strategy)
+	}
+
+	if directive == core.SupervisorRestart && a.restartLimited(strategy) {
+		log.WarnF("[braid.actor] %v exceeded max restarts %v within %v, stopping", a.Id, strategy.MaxRestarts, strategy.Window)
+		directive = core.SupervisorStop
+	}
+
+	switch directive {
+	case core.SupervisorRestart:
+		// stop consuming the mailbox, pending messages are handed over to the fresh instance
+		if atomic.CompareAndSwapInt32(&a.closed, 0, 2) {
+			atomic.StoreInt32(&a.restarting, 1)
+			close(a.closeCh)
+			go a.restart(f, strategy)
+			return true
+		}
+	case core.SupervisorStop:
+		go func() {
+			if err := a.Sys.Unregister(a.Id, a.Ty); err != nil {
+				log.WarnF("[braid.actor] %v supervisor stop err %v", a.Id, err)
+			}
+		}()
+	}
+
+	return false
+}
+
+// parent returns the id of the actor which spawned this actor, empty for a top level actor
+func (a *Runtime) parent() string {
+	if a.builder == nil {
+		return ""
+	}
+	return a.builder.GetParent()
+}
+
+// escalate hands the failure over to the strategies of the ancestors, then to the system supervisor
+//
+//	returns the directive and the strategy which decided it, its restart limits apply to the failed actor
+func (a *Runtime) escalate(f core.Failure, strategy *core.SupervisorStrategy) (core.SupervisorDirective, *core.SupervisorStrategy) {
+	for id := a.parent(); id != ""; {
+		ancestor, err := a.Sys.FindActor(context.TODO(), id)
+		if err != nil {
+			break
+		}
+		holder, ok := ancestor.(runtimeHolder)
+		if !ok {
+			break
+		}
+
+		rt := holder.runtime()
+		if rt.supervisor != nil {
+			if directive := rt.supervisor.Decide(f); directive != core.SupervisorEscalate {
+				return directive, rt.supervisor
+			}
+		}
+		id = rt.parent()
+	}
+
+	if sup, ok := a.Sys.(core.ISupervisor); ok {
+		if directive := sup.HandleFailure(f); directive != core.SupervisorEscalate {
+			return directive, strategy
+		}
+	}
+	return core.SupervisorStop, strategy
+}
+
+// restartLimited records a restart and checks it against MaxRestarts within Window
+func (a *Runtime) restartLimited(strategy *core.SupervisorStrategy) bool {
+	now := time.Now()
+
+	if strategy.Window > 0 {
+		recent := a.restarts[:0]
+		for _, t := range a.restarts {
+			if now.Sub(t) < strategy.Window {
+				recent = append(recent, t)
+			}
+		}
+		a.restarts = recent
+	}
+
+	a.restarts = append(a.restarts, now)
+	return strategy.MaxRestarts > 0 && len(a.restarts) > strategy.MaxRestarts
+}
+
+func (a *Runtime) restartBackoff(strategy *core.SupervisorStrategy) time.Duration {
+	backoff := strategy.Backoff
+	for i := 1; i < len(a.restarts) && backoff > 0; i++ {
+		backoff *= 2
+		if strategy.MaxBackoff > 0 && backoff >= strategy.MaxBackoff {
+			return strategy.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// restart exits the current instance and replaces it with a fresh one built by the actor constructor
+//
+//	the messages received during the backoff are not rejected, they are buffered in the mailbox of the
+//	failed instance (bounded by its capacity and overflow policy) and handled by the fresh instance
+func (a *Runtime) restart(f core.Failure, strategy *core.SupervisorStrategy) {
+	if backoff := a.restartBackoff(strategy); backoff > 0 {
+		time.Sleep(backoff)
+	}
+
+	old, err := a.Sys.FindActor(context.TODO(), a.Id)
+	if err != nil {
+		log.WarnF("[braid.actor] %v restart err %v", a.Id, err)
+		return
+	}
+	old.Exit()
+
+	fresh := a.builder.GetConstructor()(a.builder)
+	fresh.Init(a.initCtx)
+
+	holder, ok := fresh.(runtimeHolder)
+	if !ok {
+		log.WarnF("[braid.actor] %v restart err, actor does not embed *actor.Runtime", a.Id)
+		return
+	}
+	rt := holder.runtime()
+	rt.restarts = a.restarts
+
+	// from here on Received forwards to the fresh instance, no message can be pushed to this mailbox anymore.
+	// The pending messages are handed over before the fresh instance is reachable, so they keep their order
+	a.forwardMu.Lock()
+	a.forward = rt
+
+	a.handOverStash(rt)
+	for !a.urgentQueue.Empty() {
+		rt.urgentQueue.Push(a.urgentQueue.Pop())
+	}
+	a.q.handOver(rt.q)
+	for !a.reenterQueue.Empty() {
+		rt.reenterQueue.Push(a.reenterQueue.Pop())
+	}
+	a.forwardMu.Unlock()
+
+	if err := a.Sys.Replace(a.Id, fresh); err != nil {
+		log.WarnF("[braid.actor] %v restart replace err %v", a.Id, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restartHookTimeout)
+	defer cancel()
+	if err := rt.start(ctx, &f); err != nil {
+		log.WarnF("[braid.actor] %v restart hook err %v, stopping", a.Id, err)
+		if err := a.Sys.Unregister(a.Id, a.Ty); err != nil {
+			log.WarnF("[braid.actor] %v supervisor stop err %v", a.Id, err)
+		}
+		return
+	}
+
+	log.InfoF("[braid.actor] %v restarted, restarts in window %v", a.Id, len(a.restarts))
+}
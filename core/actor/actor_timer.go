@@ -5,17 +5,33 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pojol/braid/lib/mpsc"
+	"github.com/pojol/braid/lib/timewheel"
 )
 
 type TimerInfo struct {
 	ID       string
-	ticker   *time.Ticker
+	timer    *timewheel.Timer
+	wheel    *timewheel.TimingWheel
+	queue    *mpsc.Queue // timer queue of the owning actor
 	dueTime  time.Duration
 	interval time.Duration
 	callback func(interface{}) error
 	args     interface{}
-	active   atomic.Bool  // 使用原子操作保证线程安全
-	nextTick atomic.Value // 下次触发时间
+	cron     *cronSchedule // set for the timers registered with OnCron
+	active   atomic.Bool   // 使用原子操作保证线程安全
+	seq      atomic.Uint64 // bumped on Stop / Reset, expirations scheduled before are ignored
+	nextTick atomic.Value  // 下次触发时间
+}
+
+// timerExpiration is pushed into the actor's timer queue by the timing wheel
+type timerExpiration struct {
+	info *TimerInfo
+	seq  uint64
+}
+
+func (e *timerExpiration) valid() bool {
+	return e.info.active.Load() && e.info.seq.Load() == e.seq
 }
 
 func (t *TimerInfo) Stop() bool {
@@ -23,27 +39,35 @@ func (t *TimerInfo) Stop() bool {
 		return false
 	}
 	t.active.Store(false)
-	t.ticker.Stop()
+	t.seq.Add(1)
+	if t.timer != nil {
+		t.timer.Stop()
+	}
 
 	return true
 }
 
+// Reset restarts the timer, one-shot timers fire again after their due time and cron timers at their next scheduled time
 func (t *TimerInfo) Reset(interval time.Duration) bool {
+	if t.cron != nil {
+		t.active.Store(true)
+		return t.scheduleCron(time.Now())
+	}
+
 	if interval > 0 {
-		// 如果 interval 和 t.interval 相同，则不处理
-		if t.interval == interval {
-			return true
-		}
 		t.interval = interval
 	}
-	if t.interval <= 0 {
+
+	delay := t.interval
+	if delay <= 0 {
+		delay = t.dueTime
+	}
+	if delay <= 0 {
 		return false
 	}
 
 	t.active.Store(true)
-	t.nextTick.Store(time.Now().Add(t.interval))
-
-	t.ticker.Reset(t.interval)
+	t.schedule(delay)
 	return true
 }
 
@@ -66,6 +90,24 @@ func (t *TimerInfo) Execute() error {
 	return t.callback(t.args)
 }
 
+// schedule (re)places the timer on the timing wheel, expirations are delivered to the actor's timer queue
+func (t *TimerInfo) schedule(due time.Duration) {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	seq := t.seq.Add(1)
+	interval := t.interval
+	t.nextTick.Store(time.Now().Add(due))
+
+	t.timer = t.wheel.AfterFunc(due, interval, func() {
+		if interval > 0 {
+			t.nextTick.Store(time.Now().Add(interval))
+		}
+		t.queue.Push(&timerExpiration{info: t, seq: seq})
+	})
+}
+
 func NewTimerInfo(dueTime, interval time.Duration, callback func(interface{}) error, args interface{}) *TimerInfo {
 	t := &TimerInfo{
 		ID:       uuid.NewString(),
@@ -0,0 +1,137 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/router/msg"
+)
+
+// ICaller is the call entry of the typed API, implemented by core.ISystem, core.IActor and core.ActorContext
+type ICaller interface {
+	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error
+}
+
+type TypedParm struct {
+	// Codec encodes the request and the response into Message.Body, both sides of an event must use the same codec
+	Codec msg.ICustomSerialize // default msg pack
+
+	Ctx context.Context
+
+	// CallOptions are the per-call options of CallTyped
+	CallOptions []core.CallOption
+}
+
+type TypedOption func(*TypedParm)
+
+// TypedWithCodec sets the codec of the request and the response, e.g. &msg.ProtoSerialize{}
+func TypedWithCodec(codec msg.ICustomSerialize) TypedOption {
+	return func(tp *TypedParm) {
+		tp.Codec = codec
+	}
+}
+
+// TypedWithContext sets the context of the call, its deadline bounds the call
+func TypedWithContext(ctx context.Context) TypedOption {
+	return func(tp *TypedParm) {
+		tp.Ctx = ctx
+	}
+}
+
+// TypedWithCallOptions sets the per-call options of CallTyped, e.g. core.CallWithTimeout
+func TypedWithCallOptions(opts ...core.CallOption) TypedOption {
+	return func(tp *TypedParm) {
+		tp.CallOptions = append(tp.CallOptions, opts...)
+	}
+}
+
+func newTypedParm(opts []TypedOption) TypedParm {
+	parm := TypedParm{
+		Codec: &msg.CustomObjectSerialize{},
+		Ctx:   context.TODO(),
+	}
+	for _, opt := range opts {
+		opt(&parm)
+	}
+	return parm
+}
+
+// OnRequest registers a typed handler for the event on top of DefaultChain
+//
+//	the request is decoded from Message.Body and the response encoded back into it,
+//	an error of the handler is returned to the caller of CallTyped
+func OnRequest[Req, Res any](a core.IActor, event string, handler func(ctx core.ActorContext, req *Req) (*Res, error), opts ...TypedOption) error {
+	parm := newTypedParm(opts)
+
+	return a.OnEvent(event, func(ctx core.ActorContext) core.IChain {
+		return &DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				req := new(Req)
+				if len(mw.Req.Body) > 0 {
+					if err := parm.Codec.Decode(mw.Req.Body, req); err != nil {
+						return replyErr(mw, fmt.Errorf("braid.typed event %v decode request err %w", event, err))
+					}
+				}
+
+				res, err := handler(ctx, req)
+				if err != nil {
+					return replyErr(mw, err)
+				}
+
+				if res != nil {
+					byt, err := parm.Codec.Encode(res)
+					if err != nil {
+						return replyErr(mw, fmt.Errorf("braid.typed event %v encode response err %w", event, err))
+					}
+					mw.Res.Body = byt
+				}
+
+				return nil
+			},
+		}
+	})
+}
+
+// replyErr hands the error back to the caller, the response header carries it across nodes
+func replyErr(mw *msg.Wrapper, err error) error {
+	mw.Err = err
+	mw.Res.Header.Err = err.Error()
+	return err
+}
+
+// CallTyped calls a handler registered with OnRequest and waits for its response
+//
+//	an error of the remote handler is returned with its message only, the local handlers return the error itself
+func CallTyped[Req, Res any](ctx ICaller, idOrSymbol, actorType, event string, req *Req, opts ...TypedOption) (*Res, error) {
+	parm := newTypedParm(opts)
+
+	byt, err := parm.Codec.Encode(req)
+	if err != nil {
+		return nil, fmt.Errorf("braid.typed event %v encode request err %w", event, err)
+	}
+
+	mw := msg.NewBuilder(parm.Ctx).WithReqBody(byt).Build()
+	if err := ctx.Call(idOrSymbol, actorType, event, mw, parm.CallOptions...); err != nil {
+		return nil, err
+	}
+	if mw.Err != nil {
+		return nil, mw.Err
+	}
+	if mw.Res == nil || mw.Res.Header == nil {
+		return nil, fmt.Errorf("braid.typed event %v empty response", event)
+	}
+	if mw.Res.Header.Err != "" {
+		return nil, errors.New(mw.Res.Header.Err)
+	}
+
+	res := new(Res)
+	if len(mw.Res.Body) > 0 {
+		if err := parm.Codec.Decode(mw.Res.Body, res); err != nil {
+			return nil, fmt.Errorf("braid.typed event %v decode response err %w", event, err)
+		}
+	}
+
+	return res, nil
+}
@@ -1,6 +1,14 @@
 package core
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost is returned by KeepAlive when the node was reaped while it was alive (e.g. a long gc pause or a partition),
+// the address book took the lease again and registered the actors and groups of the node again
+var ErrLeaseLost = errors.New("[braid.addressbook] lease lost, the records of the node were registered again")
 
 type AddressInfo struct {
 	ActorId string `json:"actor_id"`
@@ -18,6 +26,13 @@ type NodeInfo struct {
 	Weight int
 }
 
+// NodeDown is the event of a node declared dead, its lease expired and its records were reaped
+type NodeDown struct {
+	Node   string
+	Actors []AddressInfo // the actors registered on the node when it was reaped
+	Time   time.Time
+}
+
 type IAddressBook interface {
 	//
 	Register(context.Context, string, string, int) error
@@ -26,8 +41,65 @@ type IAddressBook interface {
 	GetByID(context.Context, string) (AddressInfo, error)
 	GetByType(context.Context, string) ([]AddressInfo, error)
 
+	// JoinGroup adds a registered actor to a named group
+	JoinGroup(ctx context.Context, group, id string) error
+	// LeaveGroup removes an actor from a named group
+	LeaveGroup(ctx context.Context, group, id string) error
+	// GetGroup returns the members of a named group across the cluster
+	GetGroup(ctx context.Context, group string) ([]AddressInfo, error)
+
+	// GetWildcardActor picks an actor of the type with the selector, nil picks the actor on the node with the lowest weight
+	GetWildcardActor(ctx context.Context, actorType string, selector ISelector) (AddressInfo, error)
+
 	GetLowWeightNodeForActor(ctx context.Context, actorType string) (AddressInfo, error)
 	GetActorTypeCount(ctx context.Context, actorType string) (int64, error)
 
 	Clear(context.Context) error
+
+	// KeepAlive refreshes the lease of the node for ttl, the records of a node whose lease expired are reaped by the other nodes.
+	// It returns ErrLeaseLost once it restored the records of a node reaped while it was alive
+	KeepAlive(ctx context.Context, ttl time.Duration) error
+	// Reap removes the records of the other nodes whose lease expired, and logs them as down.
+	// A node which never took a lease is not reaped
+	Reap(ctx context.Context) ([]NodeDown, error)
+	// NodeDowns returns the nodes logged as down after the cursor, and the cursor of the last one
+	// (a cursor of -1 returns no node, only the current cursor)
+	NodeDowns(ctx context.Context, cursor int64) ([]NodeDown, int64, error)
+}
+
+// SelectorOption is the key of ActorConstructor.Options naming the selector of the wildcard calls to the type,
+// one of addressbook.SelectorLowestWeight (default), SelectorPowerOfTwo, SelectorRoundRobin and SelectorRandom
+const SelectorOption = "selector"
+
+// ISelector picks the actor a wildcard call is routed to among the actors of its type
+type ISelector interface {
+	// Sample is the number of random candidates fetched for a pick, 0 fetches all the actors of the type
+	Sample() int
+	// Select picks one of the candidates, weights holds the total weight of the node of each candidate
+	Select(candidates []AddressInfo, weights map[string]int) AddressInfo
+}
+
+// IAddressBookCache is implemented by an address book which caches its lookups on the node
+type IAddressBookCache interface {
+	// Invalidate drops the cached address of an actor, e.g. once a call to it failed
+	Invalidate(id string)
+	// Stats returns the hits and misses of the cached lookups
+	Stats() AddressBookCacheStats
+}
+
+// AddressBookCacheStats counts the lookups served by the address book cache
+type AddressBookCacheStats struct {
+	IDHits, IDMisses     uint64
+	TypeHits, TypeMisses uint64
+	Invalidations        uint64
+	Evictions            uint64
+}
+
+// HitRate is the share of the id and type lookups served by the cache
+func (s AddressBookCacheStats) HitRate() float64 {
+	total := s.IDHits + s.IDMisses + s.TypeHits + s.TypeMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.IDHits+s.TypeHits) / float64(total)
 }
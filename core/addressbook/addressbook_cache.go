@@ -0,0 +1,412 @@
+package addressbook
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+)
+
+const (
+	// DefaultCacheSize is the number of entries of each lookup cache
+	DefaultCacheSize = 4096
+
+	// DefaultCacheTTL bounds the age of an entry, in case an invalidation was missed
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// cacheEvent is broadcast over the CacheTransport when the records of an actor or a node change
+type cacheEvent struct {
+	Op      string `json:"op"` // register, unregister, node (cleared, reaped or restored)
+	ActorID string `json:"actor_id,omitempty"`
+	ActorTy string `json:"actor_ty,omitempty"`
+	Node    string `json:"node,omitempty"`
+}
+
+// cacheSource is implemented by the address books of this package, it exposes what the cache needs
+// to tag its events and to pick wildcard actors out of the cached types
+type cacheSource interface {
+	nodeID() string
+	// nodeWeights returns the weights of the nodes, the unknown nodes are left out
+	nodeWeights(ctx context.Context, nodes []string) (map[string]int, error)
+}
+
+// Cache is a node-local cache of the id and type lookups of an address book
+//
+//	entries are invalidated by the register and unregister events the caches broadcast over their transport,
+//	and expire after a short ttl in case an event was missed. Groups and node weights are not cached
+type Cache struct {
+	core.IAddressBook
+
+	ids   *lru // actor id -> core.AddressInfo
+	types *lru // actor type -> []core.AddressInfo
+
+	// the types of the actors registered through this cache, their unregister names the type to invalidate
+	localTypes map[string]string
+	localMu    sync.Mutex
+
+	// bumped by every invalidation, a lookup which raced with an invalidation is not cached
+	gen uint64
+
+	idHits, idMisses, typeHits, typeMisses uint64
+	invalidations                          uint64
+
+	transport CacheTransport
+	close     func()
+}
+
+// NewCache wraps an address book with a cache of size entries per lookup, 0 uses the defaults.
+// The invalidations are exchanged over transport, see DefaultCacheTransport, nil leaves the entries to expire
+func NewCache(ab core.IAddressBook, transport CacheTransport, size int, ttl time.Duration) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	c := &Cache{
+		IAddressBook: ab,
+		ids:          newLRU(size, ttl),
+		types:        newLRU(size, ttl),
+		localTypes:   make(map[string]string),
+		transport:    transport,
+	}
+
+	if transport != nil {
+		events, close, err := transport.Subscribe(context.TODO())
+		if err != nil {
+			log.WarnF("[braid.addressbook] cache subscribe invalidations err %v, entries only expire", err)
+		} else {
+			c.close = close
+			go c.listen(events)
+		}
+	}
+
+	return c
+}
+
+func (c *Cache) listen(events <-chan []byte) {
+	for byt := range events {
+		var ev cacheEvent
+		if err := json.Unmarshal(byt, &ev); err != nil {
+			continue
+		}
+		c.invalidate(ev)
+	}
+}
+
+func (c *Cache) invalidate(ev cacheEvent) {
+	atomic.AddUint64(&c.gen, 1)
+	atomic.AddUint64(&c.invalidations, 1)
+
+	switch ev.Op {
+	case "node":
+		c.ids.removeIf(func(v interface{}) bool { return v.(core.AddressInfo).Node == ev.Node })
+		c.types.clear()
+	default:
+		c.ids.remove(ev.ActorID)
+		if ev.ActorTy != "" {
+			c.types.remove(ev.ActorTy)
+		} else {
+			c.types.clear()
+		}
+	}
+}
+
+func (c *Cache) publish(ctx context.Context, ev cacheEvent) {
+	c.invalidate(ev) // the events of this node are applied at once, not only once they come back
+	if c.transport == nil {
+		return
+	}
+
+	byt, _ := json.Marshal(ev)
+	if err := c.transport.Publish(ctx, byt); err != nil {
+		log.WarnF("[braid.addressbook] cache publish %v err %v", ev.Op, err)
+	}
+}
+
+func (c *Cache) Register(ctx context.Context, ty, id string, weight int) error {
+	err := c.IAddressBook.Register(ctx, ty, id, weight)
+	if err == nil {
+		c.localMu.Lock()
+		c.localTypes[id] = ty
+		c.localMu.Unlock()
+
+		c.publish(ctx, cacheEvent{Op: "register", ActorID: id, ActorTy: ty, Node: c.node()})
+	}
+	return err
+}
+
+func (c *Cache) Unregister(ctx context.Context, id string, weight int) error {
+	ty := c.typeOf(ctx, id)
+
+	err := c.IAddressBook.Unregister(ctx, id, weight)
+	if err == nil {
+		c.localMu.Lock()
+		delete(c.localTypes, id)
+		c.localMu.Unlock()
+
+		c.publish(ctx, cacheEvent{Op: "unregister", ActorID: id, ActorTy: ty, Node: c.node()})
+	}
+	return err
+}
+
+// typeOf returns the type of an actor, from the actors registered through this cache,
+// the cached addresses or else the address book. "" if it is not known
+func (c *Cache) typeOf(ctx context.Context, id string) string {
+	c.localMu.Lock()
+	ty, ok := c.localTypes[id]
+	c.localMu.Unlock()
+	if ok {
+		return ty
+	}
+
+	if v, ok := c.ids.get(id); ok && v.(core.AddressInfo).ActorTy != "" {
+		return v.(core.AddressInfo).ActorTy
+	}
+	if info, err := c.IAddressBook.GetByID(ctx, id); err == nil {
+		return info.ActorTy
+	}
+	return ""
+}
+
+func (c *Cache) GetByID(ctx context.Context, id string) (core.AddressInfo, error) {
+	if v, ok := c.ids.get(id); ok {
+		atomic.AddUint64(&c.idHits, 1)
+		return v.(core.AddressInfo), nil
+	}
+	atomic.AddUint64(&c.idMisses, 1)
+
+	gen := atomic.LoadUint64(&c.gen)
+	info, err := c.IAddressBook.GetByID(ctx, id)
+	if err == nil && atomic.LoadUint64(&c.gen) == gen {
+		c.ids.set(id, info)
+	}
+	return info, err
+}
+
+func (c *Cache) GetByType(ctx context.Context, actorType string) ([]core.AddressInfo, error) {
+	if v, ok := c.types.get(actorType); ok {
+		atomic.AddUint64(&c.typeHits, 1)
+		return append([]core.AddressInfo{}, v.([]core.AddressInfo)...), nil
+	}
+	atomic.AddUint64(&c.typeMisses, 1)
+
+	gen := atomic.LoadUint64(&c.gen)
+	addrs, err := c.IAddressBook.GetByType(ctx, actorType)
+	if err == nil && atomic.LoadUint64(&c.gen) == gen {
+		c.types.set(actorType, append([]core.AddressInfo{}, addrs...))
+	}
+	return addrs, err
+}
+
+// GetWildcardActor picks with the selector among the cached actors of the type,
+// the weights of their nodes are read from the address book as they change with every register
+func (c *Cache) GetWildcardActor(ctx context.Context, actorType string, selector core.ISelector) (core.AddressInfo, error) {
+	src, ok := c.IAddressBook.(cacheSource)
+	if !ok {
+		return c.IAddressBook.GetWildcardActor(ctx, actorType, selector)
+	}
+	if selector == nil {
+		selector = NewSelector(SelectorLowestWeight)
+	}
+
+	addrs, err := c.GetByType(ctx, actorType)
+	if err != nil || len(addrs) == 0 {
+		return c.IAddressBook.GetWildcardActor(ctx, actorType, selector) // the error of the address book
+	}
+	addrs = sample(addrs, selector.Sample())
+
+	nodes := make([]string, 0, len(addrs))
+	seen := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		if _, ok := seen[addr.Node]; !ok {
+			seen[addr.Node] = struct{}{}
+			nodes = append(nodes, addr.Node)
+		}
+	}
+	weights, err := src.nodeWeights(ctx, nodes)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+
+	// the actors on a node without a weight are left out, the node is gone
+	candidates := addrs[:0]
+	for _, addr := range addrs {
+		if _, ok := weights[addr.Node]; ok {
+			candidates = append(candidates, addr)
+		}
+	}
+	if len(candidates) == 0 {
+		return c.IAddressBook.GetWildcardActor(ctx, actorType, selector)
+	}
+
+	return selector.Select(candidates, weights), nil
+}
+
+// Clear clears the records of the node, it is called once the node exits and also stops the invalidations
+func (c *Cache) Clear(ctx context.Context) error {
+	err := c.IAddressBook.Clear(ctx)
+	if err == nil {
+		c.publish(ctx, cacheEvent{Op: "node", Node: c.node()})
+	}
+
+	if c.close != nil {
+		c.close()
+	}
+	return err
+}
+
+// KeepAlive drops the caches of the other nodes once the records of this node were registered again
+func (c *Cache) KeepAlive(ctx context.Context, ttl time.Duration) error {
+	err := c.IAddressBook.KeepAlive(ctx, ttl)
+	if errors.Is(err, core.ErrLeaseLost) {
+		c.publish(ctx, cacheEvent{Op: "node", Node: c.node()})
+	}
+	return err
+}
+
+func (c *Cache) Reap(ctx context.Context) ([]core.NodeDown, error) {
+	downs, err := c.IAddressBook.Reap(ctx)
+	for _, down := range downs {
+		c.publish(ctx, cacheEvent{Op: "node", Node: down.Node})
+	}
+	return downs, err
+}
+
+func (c *Cache) node() string {
+	if src, ok := c.IAddressBook.(cacheSource); ok {
+		return src.nodeID()
+	}
+	return ""
+}
+
+// Invalidate drops the cached address of an actor and the cached types which list it, e.g. once a call to it failed
+func (c *Cache) Invalidate(id string) {
+	ty, node := "", ""
+	if v, ok := c.ids.get(id); ok {
+		ty, node = v.(core.AddressInfo).ActorTy, v.(core.AddressInfo).Node
+	}
+	c.invalidate(cacheEvent{Op: "unregister", ActorID: id, ActorTy: ty, Node: node})
+}
+
+// Stats returns the hits and misses of the lookups since the cache was created
+func (c *Cache) Stats() core.AddressBookCacheStats {
+	return core.AddressBookCacheStats{
+		IDHits:        atomic.LoadUint64(&c.idHits),
+		IDMisses:      atomic.LoadUint64(&c.idMisses),
+		TypeHits:      atomic.LoadUint64(&c.typeHits),
+		TypeMisses:    atomic.LoadUint64(&c.typeMisses),
+		Invalidations: atomic.LoadUint64(&c.invalidations),
+		Evictions:     c.ids.evicted() + c.types.evicted(),
+	}
+}
+
+// lru is a size bounded cache with a ttl, the least recently used entry is evicted first
+type lru struct {
+	size int
+	ttl  time.Duration
+
+	entries  map[string]*list.Element
+	order    *list.List // front is the most recently used
+	evictedN uint64
+
+	sync.Mutex
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (l *lru) get(key string) (interface{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		l.order.Remove(el)
+		delete(l.entries, key)
+		return nil, false
+	}
+
+	l.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (l *lru) set(key string, value interface{}) {
+	l.Lock()
+	defer l.Unlock()
+
+	if el, ok := l.entries[key]; ok {
+		el.Value = &lruEntry{key: key, value: value, expires: time.Now().Add(l.ttl)}
+		l.order.MoveToFront(el)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: time.Now().Add(l.ttl)})
+	if l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+		l.evictedN++
+	}
+}
+
+func (l *lru) remove(key string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if el, ok := l.entries[key]; ok {
+		l.order.Remove(el)
+		delete(l.entries, key)
+	}
+}
+
+func (l *lru) removeIf(f func(interface{}) bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	for key, el := range l.entries {
+		if f(el.Value.(*lruEntry).value) {
+			l.order.Remove(el)
+			delete(l.entries, key)
+		}
+	}
+}
+
+func (l *lru) clear() {
+	l.Lock()
+	defer l.Unlock()
+
+	l.entries = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+func (l *lru) evicted() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return l.evictedN
+}
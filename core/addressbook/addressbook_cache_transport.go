@@ -0,0 +1,104 @@
+package addressbook
+
+import (
+	"context"
+	"sync"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+)
+
+// CacheTransport carries the invalidation events between the address book caches of the nodes
+type CacheTransport interface {
+	Publish(ctx context.Context, event []byte) error
+	// Subscribe returns the events published by every cache, this one included, until close is called
+	Subscribe(ctx context.Context) (events <-chan []byte, close func(), err error)
+}
+
+// DefaultCacheTransport returns the transport of the caches of an address book,
+// the store shared by the memory books, redis pubsub for the others
+func DefaultCacheTransport(ab core.IAddressBook) CacheTransport {
+	switch b := ab.(type) {
+	case *MemoryAddressBook:
+		return b.store.cacheTransport()
+	case *Cache:
+		return DefaultCacheTransport(b.IAddressBook)
+	}
+	return NewRedisCacheTransport()
+}
+
+type redisCacheTransport struct{}
+
+// NewRedisCacheTransport publishes the invalidations on def.RedisAddressbookEventChannel
+func NewRedisCacheTransport() CacheTransport {
+	return redisCacheTransport{}
+}
+
+func (redisCacheTransport) Publish(ctx context.Context, event []byte) error {
+	return trdredis.GetClient().Publish(ctx, def.RedisAddressbookEventChannel, event).Err()
+}
+
+func (redisCacheTransport) Subscribe(ctx context.Context) (<-chan []byte, func(), error) {
+	sub := trdredis.GetClient().Subscribe(ctx, def.RedisAddressbookEventChannel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+
+	events := make(chan []byte)
+	go func() {
+		defer close(events)
+		for m := range sub.Channel() {
+			events <- []byte(m.Payload)
+		}
+	}()
+
+	return events, func() { sub.Close() }, nil
+}
+
+// memoryCacheTransportBuffer is the number of events a subscriber may lag behind,
+// the events over it are dropped and the entries they concern only expire
+const memoryCacheTransportBuffer = 256
+
+// memoryCacheTransport fans the invalidations out to the caches of the process
+type memoryCacheTransport struct {
+	subs map[chan []byte]struct{}
+	sync.Mutex
+}
+
+// NewMemoryCacheTransport creates a transport between the caches of a process
+func NewMemoryCacheTransport() CacheTransport {
+	return &memoryCacheTransport{subs: make(map[chan []byte]struct{})}
+}
+
+func (t *memoryCacheTransport) Publish(ctx context.Context, event []byte) error {
+	t.Lock()
+	defer t.Unlock()
+
+	for sub := range t.subs {
+		select {
+		case sub <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+func (t *memoryCacheTransport) Subscribe(ctx context.Context) (<-chan []byte, func(), error) {
+	sub := make(chan []byte, memoryCacheTransportBuffer)
+
+	t.Lock()
+	t.subs[sub] = struct{}{}
+	t.Unlock()
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			t.Lock()
+			delete(t.subs, sub)
+			close(sub)
+			t.Unlock()
+		})
+	}, nil
+}
@@ -3,7 +3,6 @@ package addressbook
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -17,7 +16,7 @@ import (
 )
 
 var (
-	ErrUnknownActor = errors.New("unknown actor")
+	ErrUnknownActor = core.ErrUnknownActor
 )
 
 type AddressBook struct {
@@ -27,20 +26,55 @@ type AddressBook struct {
 
 	IDMap map[string]bool
 
+	// the local actors and the groups they joined, registered again if the node loses its lease
+	actors map[string]localActor
+	groups map[string]map[string]struct{} // actor id -> group names
+
+	leased bool // the node took its lease
+
 	sync.RWMutex
 }
 
+type localActor struct {
+	ty     string
+	weight int
+}
+
 func New(info core.AddressInfo) *AddressBook {
 	return &AddressBook{
 		IDMap:  make(map[string]bool),
+		actors: make(map[string]localActor),
+		groups: make(map[string]map[string]struct{}),
 		NodeID: info.Node,
 		Ip:     info.Ip,
 		Port:   info.Port,
 	}
 }
 
+// the key of the hash of the weights of a node
+const nodeKeyPrefix, nodeKeySuffix = "{node:", "}"
+
 func makeNodeKey(nodid string) string {
-	return fmt.Sprintf("{node:%s}", nodid)
+	return nodeKeyPrefix + nodid + nodeKeySuffix
+}
+
+func makeGroupKey(group string) string {
+	return def.RedisAddressbookGroupField + group
+}
+
+func makeNodeGroupsKey(nodid string) string {
+	return def.RedisAddressbookNodeGroupsField + nodid
+}
+
+// groupEntry is a member of the node groups set, it names a group and the exact member of its set
+type groupEntry struct {
+	Group  string `json:"group"`
+	Member string `json:"member"`
+}
+
+func makeGroupEntry(group, member string) string {
+	byt, _ := json.Marshal(groupEntry{Group: group, Member: member})
+	return string(byt)
 }
 
 func (ab *AddressBook) Register(ctx context.Context, ty, id string, weight int) error {
@@ -55,21 +89,7 @@ func (ab *AddressBook) Register(ctx context.Context, ty, id string, weight int)
 	}
 	ab.RUnlock()
 
-	// serialize address info to json
-	addrJSON, _ := json.Marshal(core.AddressInfo{
-		Node:    ab.NodeID,
-		ActorId: id,
-		ActorTy: ty,
-		Ip:      ab.Ip,
-		Port:    ab.Port},
-	)
-
-	// serialize node info to json (without ActorId and ActorTy)
-	nodeInfoJSON, _ := json.Marshal(core.AddressInfo{
-		Node: ab.NodeID,
-		Ip:   ab.Ip,
-		Port: ab.Port},
-	)
+	addrJSON, nodeInfoJSON := ab.encode(ty, id), ab.encodeNode()
 
 	// execute multiple redis operations using pipeline
 	pipe := trdredis.Pipeline()
@@ -82,6 +102,7 @@ func (ab *AddressBook) Register(ctx context.Context, ty, id string, weight int)
 	// 更新节点记录
 	pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), fmt.Sprintf("actor:%s", ty), int64(weight))
 	pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), "total_weight", int64(weight))
+	pipe.HIncrBy(ctx, def.RedisAddressbookWeightsField, ab.NodeID, int64(weight))
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -90,11 +111,34 @@ func (ab *AddressBook) Register(ctx context.Context, ty, id string, weight int)
 
 	ab.Lock()
 	ab.IDMap[id] = true
+	ab.actors[id] = localActor{ty: ty, weight: weight}
 	ab.Unlock()
 
 	return nil
 }
 
+// encode serializes the address of a local actor, the member of its type and group sets
+func (ab *AddressBook) encode(ty, id string) string {
+	byt, _ := json.Marshal(core.AddressInfo{
+		Node:    ab.NodeID,
+		ActorId: id,
+		ActorTy: ty,
+		Ip:      ab.Ip,
+		Port:    ab.Port},
+	)
+	return string(byt)
+}
+
+// encodeNode serializes the node info (without ActorId and ActorTy)
+func (ab *AddressBook) encodeNode() string {
+	byt, _ := json.Marshal(core.AddressInfo{
+		Node: ab.NodeID,
+		Ip:   ab.Ip,
+		Port: ab.Port},
+	)
+	return string(byt)
+}
+
 func (ab *AddressBook) Unregister(ctx context.Context, id string, weight int) error {
 	if id == "" {
 		return fmt.Errorf("actor id or type is empty")
@@ -120,11 +164,21 @@ func (ab *AddressBook) Unregister(ctx context.Context, id string, weight int) er
 	// 更新节点记录
 	pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), fmt.Sprintf("actor:%s", info.ActorTy), int64(-weight))
 	pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), "total_weight", int64(-weight))
+	pipe.HIncrBy(ctx, def.RedisAddressbookWeightsField, ab.NodeID, int64(-weight))
+
+	ab.RLock()
+	for group := range ab.groups[id] {
+		pipe.SRem(ctx, makeGroupKey(group), addrJSON)
+		pipe.SRem(ctx, makeNodeGroupsKey(info.Node), makeGroupEntry(group, addrJSON))
+	}
+	ab.RUnlock()
 
 	_, err = pipe.Exec(ctx)
 	if err == nil {
 		ab.Lock()
 		delete(ab.IDMap, id) // try delete
+		delete(ab.actors, id)
+		delete(ab.groups, id)
 		ab.Unlock()
 	}
 
@@ -182,57 +236,191 @@ func (ab *AddressBook) GetByType(ctx context.Context, actorType string) ([]core.
 	return addresses, nil
 }
 
+// JoinGroup adds a registered actor to a named group
+func (ab *AddressBook) JoinGroup(ctx context.Context, group, id string) error {
+	if group == "" || id == "" {
+		return fmt.Errorf("actor id or group is empty")
+	}
+
+	addrJSON, err := trdredis.HGet(ctx, def.RedisAddressbookIDField, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrUnknownActor
+		}
+		return fmt.Errorf("[braid.addressbook] join group %s hget err: %s", group, err.Error())
+	}
+
+	var info core.AddressInfo
+	if err := json.Unmarshal([]byte(addrJSON), &info); err != nil {
+		return fmt.Errorf("[braid.addressbook] join group %s json unmarshal err: %s", group, err.Error())
+	}
+
+	// the membership is indexed under the node of the actor, it is removed with the records of the node
+	pipe := trdredis.Pipeline()
+	pipe.SAdd(ctx, makeGroupKey(group), addrJSON)
+	pipe.SAdd(ctx, makeNodeGroupsKey(info.Node), makeGroupEntry(group, addrJSON))
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("[braid.addressbook] join group %s sadd err: %s", group, err.Error())
+	}
+
+	ab.Lock()
+	if _, ok := ab.groups[id]; !ok {
+		ab.groups[id] = make(map[string]struct{})
+	}
+	ab.groups[id][group] = struct{}{}
+	ab.Unlock()
+
+	return nil
+}
+
+// LeaveGroup removes an actor from a named group
+func (ab *AddressBook) LeaveGroup(ctx context.Context, group, id string) error {
+	if group == "" || id == "" {
+		return fmt.Errorf("actor id or group is empty")
+	}
+
+	addrJSON, err := trdredis.HGet(ctx, def.RedisAddressbookIDField, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrUnknownActor
+		}
+		return fmt.Errorf("[braid.addressbook] leave group %s hget err: %s", group, err.Error())
+	}
+
+	var info core.AddressInfo
+	if err := json.Unmarshal([]byte(addrJSON), &info); err != nil {
+		return fmt.Errorf("[braid.addressbook] leave group %s json unmarshal err: %s", group, err.Error())
+	}
+
+	pipe := trdredis.Pipeline()
+	pipe.SRem(ctx, makeGroupKey(group), addrJSON)
+	pipe.SRem(ctx, makeNodeGroupsKey(info.Node), makeGroupEntry(group, addrJSON))
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("[braid.addressbook] leave group %s srem err: %s", group, err.Error())
+	}
+
+	ab.Lock()
+	delete(ab.groups[id], group)
+	ab.Unlock()
+
+	return nil
+}
+
+// GetGroup get the members of a named group
+func (ab *AddressBook) GetGroup(ctx context.Context, group string) ([]core.AddressInfo, error) {
+	addrJSONs, err := trdredis.SMembers(ctx, makeGroupKey(group)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members for group: %s", group)
+	}
+
+	members := make([]core.AddressInfo, 0, len(addrJSONs))
+	for _, addrJSON := range addrJSONs {
+		var addr core.AddressInfo
+		err = json.Unmarshal([]byte(addrJSON), &addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal address: %v", err)
+		}
+		members = append(members, addr)
+	}
+
+	return members, nil
+}
+
 const (
 	PickLimit          = 10
 	LowWeightNodeLimit = 10 // Number of low weight nodes to consider
 )
 
-// GetWildcardActor retrieves a random actor address of the specified actorType
-func (ab *AddressBook) GetWildcardActor(ctx context.Context, actorType string) (core.AddressInfo, error) {
-	key := fmt.Sprintf(def.RedisAddressbookTyField+"%s", actorType)
+// GetWildcardActor retrieves an actor address of the specified actorType, picked by the selector
+//
+//	the actors are sampled from the type set, the weights of the nodes are read in the same pipeline.
+//	The actors on a node without a weight are left out, if the sample only holds such actors the pick
+//	falls back to the whole set
+func (ab *AddressBook) GetWildcardActor(ctx context.Context, actorType string, selector core.ISelector) (core.AddressInfo, error) {
+	if selector == nil {
+		selector = NewSelector(SelectorLowestWeight)
+	}
 
-	// get a random one
-	addrJSONs, err := trdredis.SRandMemberN(ctx, key, PickLimit).Result()
+	n := selector.Sample()
+	candidates, weights, err := ab.sampleWildcard(ctx, actorType, n)
+	if err == nil && len(candidates) == 0 && n > 0 {
+		candidates, weights, err = ab.sampleWildcard(ctx, actorType, 0)
+	}
 	if err != nil {
-		if err == redis.Nil {
-			return core.AddressInfo{}, fmt.Errorf("no actors found for type %s", actorType)
-		}
-		return core.AddressInfo{}, fmt.Errorf("GetWildcardActor SRandMember err %v", err)
+		return core.AddressInfo{}, fmt.Errorf("[braid.addressbook] get wildcard actor %v err %w", actorType, err)
 	}
 
-	// unmarshal
-	if len(addrJSONs) == 0 {
+	if len(candidates) == 0 {
 		return core.AddressInfo{}, fmt.Errorf("no actors found for type %s", actorType)
 	}
 
-	var lowestWeightAddr core.AddressInfo
-	lowestWeight := int(^uint(0) >> 1) // // Maximum int value, used as a sentinel to check if a valid weighted node address has been found
+	return selector.Select(candidates, weights), nil
+}
 
-	for _, addrJSON := range addrJSONs {
+// sampleWildcard reads n random actors of the type (all of them if n <= 0) and the weights of the nodes in one round trip,
+// returns the actors on a node with a weight
+func (ab *AddressBook) sampleWildcard(ctx context.Context, actorType string, n int) ([]core.AddressInfo, map[string]int, error) {
+	key := fmt.Sprintf(def.RedisAddressbookTyField+"%s", actorType)
+
+	pipe := trdredis.Pipeline()
+	var members *redis.StringSliceCmd
+	if n > 0 {
+		members = pipe.SRandMemberN(ctx, key, int64(n))
+	} else {
+		members = pipe.SMembers(ctx, key)
+	}
+	nodeWeights := pipe.HGetAll(ctx, def.RedisAddressbookWeightsField)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	weights := make(map[string]int)
+	for node, weightStr := range nodeWeights.Val() {
+		if weight, err := strconv.Atoi(weightStr); err == nil {
+			weights[node] = weight
+		}
+	}
+
+	candidates := make([]core.AddressInfo, 0, len(members.Val()))
+	for _, member := range members.Val() {
 		var addr core.AddressInfo
-		if err := json.Unmarshal([]byte(addrJSON), &addr); err != nil {
+		if err := json.Unmarshal([]byte(member), &addr); err != nil {
 			log.WarnF("addressbook unmarshal actor type %v json err %v", actorType, err.Error())
-			continue // continue to next address
+			continue
 		}
-
-		// get the weight of the node where the actor is located
-		nodeWeight, err := trdredis.HGet(ctx, makeNodeKey(addr.Node), "total_weight").Int()
-		if err != nil {
-			fmt.Println("skip this actor if unable to get node weight")
-			continue // skip this actor if unable to get node weight
+		if _, ok := weights[addr.Node]; ok {
+			candidates = append(candidates, addr)
 		}
+	}
 
-		if nodeWeight < lowestWeight {
-			lowestWeight = nodeWeight
-			lowestWeightAddr = addr
-		}
+	return candidates, weights, nil
+}
+
+func (ab *AddressBook) nodeID() string {
+	return ab.NodeID
+}
+
+func (ab *AddressBook) nodeWeights(ctx context.Context, nodes []string) (map[string]int, error) {
+	weights := make(map[string]int, len(nodes))
+	if len(nodes) == 0 {
+		return weights, nil
 	}
 
-	if lowestWeight == int(^uint(0)>>1) {
-		return core.AddressInfo{}, fmt.Errorf("no valid actors found for type %s", actorType)
+	vals, err := trdredis.GetClient().HMGet(ctx, def.RedisAddressbookWeightsField, nodes...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[braid.addressbook] get node weights err %w", err)
 	}
 
-	return lowestWeightAddr, nil
+	for i, val := range vals {
+		if s, ok := val.(string); ok {
+			if weight, err := strconv.Atoi(s); err == nil {
+				weights[nodes[i]] = weight
+			}
+		}
+	}
+	return weights, nil
 }
 
 // GetLowWeightNodeForActor retrieves a low-weight node address with fewer actors of the specified type
@@ -322,14 +510,41 @@ func (ab *AddressBook) Clear(ctx context.Context) error {
 	}
 	defer dismutex.Unlock(ctx, ab.NodeID, mid)
 
+	pipe := trdredis.Pipeline()
+
+	if _, err := clearNode(ctx, pipe, ab.NodeID); err != nil {
+		return err
+	}
+
+	pipe.ZRem(ctx, def.RedisAddressbookLeasesField, ab.NodeID)
+
+	// 执行 pipeline
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear node data: %w", err)
+	}
+
+	// the records are gone, a later lease has nothing to restore
+	ab.Lock()
+	ab.IDMap = make(map[string]bool)
+	ab.actors = make(map[string]localActor)
+	ab.groups = make(map[string]map[string]struct{})
+	ab.leased = false
+	ab.Unlock()
+
+	return nil
+}
+
+// clearNode queues the removal of the actors, their group memberships and the records of a node, returns the removed actors
+func clearNode(ctx context.Context, pipe redis.Pipeliner, nodeID string) ([]core.AddressInfo, error) {
 	// 获取该节点的所有 actor 信息
-	nodeKey := makeNodeKey(ab.NodeID)
+	nodeKey := makeNodeKey(nodeID)
 	actorInfos, err := trdredis.HGetAll(ctx, nodeKey).Result()
 	if err != nil {
-		return fmt.Errorf("failed to get actor infos: %w", err)
+		return nil, fmt.Errorf("failed to get actor infos: %w", err)
 	}
 
-	pipe := trdredis.Pipeline()
+	var removed []core.AddressInfo
 
 	// 删除该节点的所有 actor 信息
 	for actorType := range actorInfos {
@@ -341,7 +556,7 @@ func (ab *AddressBook) Clear(ctx context.Context) error {
 		// 获取该类型的所有 actor
 		actors, err := trdredis.SMembers(ctx, actorTypeKey).Result()
 		if err != nil {
-			return fmt.Errorf("failed to get actors of type %s: %w", actorType, err)
+			return nil, fmt.Errorf("failed to get actors of type %s: %w", actorType, err)
 		}
 
 		for _, actorJSON := range actors {
@@ -349,22 +564,32 @@ func (ab *AddressBook) Clear(ctx context.Context) error {
 			if err := json.Unmarshal([]byte(actorJSON), &actor); err != nil {
 				continue
 			}
-			if actor.Node == ab.NodeID {
-				log.InfoF("addressbook clear node %v actor %v", ab.NodeID, actor.ActorId)
+			if actor.Node == nodeID {
+				log.InfoF("addressbook clear node %v actor %v", nodeID, actor.ActorId)
 				pipe.SRem(ctx, actorTypeKey, actorJSON)
 				pipe.HDel(ctx, def.RedisAddressbookIDField, actor.ActorId)
+				removed = append(removed, actor)
 			}
 		}
 	}
 
-	pipe.Del(ctx, nodeKey)
-	pipe.HDel(ctx, def.RedisAddressbookNodesField, ab.NodeID)
-
-	// 执行 pipeline
-	_, err = pipe.Exec(ctx)
+	// 删除该节点 actor 的分组信息
+	entries, err := trdredis.SMembers(ctx, makeNodeGroupsKey(nodeID)).Result()
 	if err != nil {
-		return fmt.Errorf("failed to clear node data: %w", err)
+		return nil, fmt.Errorf("failed to get groups of node %s: %w", nodeID, err)
 	}
+	for _, entry := range entries {
+		var ge groupEntry
+		if err := json.Unmarshal([]byte(entry), &ge); err != nil {
+			continue
+		}
+		pipe.SRem(ctx, makeGroupKey(ge.Group), ge.Member)
+	}
+	pipe.Del(ctx, makeNodeGroupsKey(nodeID))
 
-	return nil
+	pipe.Del(ctx, nodeKey)
+	pipe.HDel(ctx, def.RedisAddressbookNodesField, nodeID)
+	pipe.HDel(ctx, def.RedisAddressbookWeightsField, nodeID)
+
+	return removed, nil
 }
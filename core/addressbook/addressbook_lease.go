@@ -0,0 +1,241 @@
+package addressbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/dismutex"
+	"github.com/pojol/braid/lib/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// NodeDownLogLimit is the number of node downs kept in the log
+const NodeDownLogLimit = 256
+
+// refresh the lease of a node, unless it was reaped
+//
+//	KEYS[1] the leases, ARGV[1] the node, ARGV[2] the expiry of the lease
+var keepAliveScript = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+	return 1
+end
+return 0`)
+
+// KeepAlive refreshes the lease of the node
+//
+//	a lease which is gone once the node took it was reaped while the node was alive,
+//	the records of the node are registered again and ErrLeaseLost is returned
+func (ab *AddressBook) KeepAlive(ctx context.Context, ttl time.Duration) error {
+	expiry := time.Now().Add(ttl).UnixMilli()
+
+	ab.RLock()
+	leased := ab.leased
+	ab.RUnlock()
+
+	if !leased {
+		err := trdredis.ZAdd(ctx, def.RedisAddressbookLeasesField, redis.Z{Score: float64(expiry), Member: ab.NodeID}).Err()
+		if err != nil {
+			return err
+		}
+
+		ab.Lock()
+		ab.leased = true
+		ab.Unlock()
+		return nil
+	}
+
+	kept, err := trdredis.ScriptRun(ctx, keepAliveScript, []string{def.RedisAddressbookLeasesField}, ab.NodeID, expiry)
+	if err != nil {
+		return err
+	}
+	if n, _ := kept.(int64); n == 1 {
+		return nil
+	}
+
+	if err := ab.restore(ctx, expiry); err != nil {
+		return fmt.Errorf("[braid.addressbook] node %v lease lost, restore err %w", ab.NodeID, err)
+	}
+	log.WarnF("[braid.addressbook] node %v lease lost, its records were registered again", ab.NodeID)
+	return core.ErrLeaseLost
+}
+
+// restore registers the local actors and their groups again and takes the lease, once the node was reaped
+//
+//	it holds the distributed mutex of the node, so it does not interleave with a reap.
+//	an actor which was activated on another node in the meantime is left to that node
+func (ab *AddressBook) restore(ctx context.Context, expiry int64) error {
+	mid, err := dismutex.Lock(ctx, ab.NodeID)
+	if err != nil {
+		return err
+	}
+	defer dismutex.Unlock(ctx, ab.NodeID, mid)
+
+	ab.RLock()
+	actors := make(map[string]localActor, len(ab.actors))
+	for id, actor := range ab.actors {
+		actors[id] = actor
+	}
+	groups := make(map[string][]string, len(ab.groups))
+	for id, names := range ab.groups {
+		for group := range names {
+			groups[id] = append(groups[id], group)
+		}
+	}
+	ab.RUnlock()
+
+	pipe := trdredis.Pipeline()
+	claims := make(map[string]*redis.BoolCmd, len(actors))
+	for id, actor := range actors {
+		claims[id] = pipe.HSetNX(ctx, def.RedisAddressbookIDField, id, ab.encode(actor.ty, id))
+	}
+	if len(claims) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	pipe = trdredis.Pipeline()
+	for id, actor := range actors {
+		if !claims[id].Val() {
+			log.WarnF("[braid.addressbook] node %v restore actor %v, registered on another node", ab.NodeID, id)
+			continue
+		}
+
+		addrJSON := ab.encode(actor.ty, id)
+		pipe.SAdd(ctx, def.RedisAddressbookTyField+actor.ty, addrJSON)
+		pipe.HSet(ctx, def.RedisAddressbookNodesField, ab.NodeID, ab.encodeNode())
+		pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), "actor:"+actor.ty, int64(actor.weight))
+		pipe.HIncrBy(ctx, makeNodeKey(ab.NodeID), "total_weight", int64(actor.weight))
+		pipe.HIncrBy(ctx, def.RedisAddressbookWeightsField, ab.NodeID, int64(actor.weight))
+
+		for _, group := range groups[id] {
+			pipe.SAdd(ctx, makeGroupKey(group), addrJSON)
+			pipe.SAdd(ctx, makeNodeGroupsKey(ab.NodeID), makeGroupEntry(group, addrJSON))
+		}
+	}
+	pipe.ZAdd(ctx, def.RedisAddressbookLeasesField, redis.Z{Score: float64(expiry), Member: ab.NodeID})
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Reap removes the records of the nodes whose lease expired
+//
+//	a node is reaped under the distributed mutex of its id, the one Clear holds on a graceful exit,
+//	so a node is reaped once, and never while it clears its own records.
+//	the nodes which never took a lease (e.g. an older version) are left alone
+func (ab *AddressBook) Reap(ctx context.Context) ([]core.NodeDown, error) {
+	nodes, err := trdredis.ZRangeByScore(ctx, def.RedisAddressbookLeasesField, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[braid.addressbook] reap get expired leases err %w", err)
+	}
+
+	var downs []core.NodeDown
+	for _, nodeID := range nodes {
+		if nodeID == ab.NodeID {
+			continue
+		}
+
+		down, err := ab.reapNode(ctx, nodeID)
+		if err != nil {
+			log.WarnF("[braid.addressbook] reap node %v err %v", nodeID, err)
+			continue
+		}
+		if down != nil {
+			downs = append(downs, *down)
+		}
+	}
+
+	return downs, nil
+}
+
+func (ab *AddressBook) reapNode(ctx context.Context, nodeID string) (*core.NodeDown, error) {
+	mid, err := dismutex.Lock(ctx, nodeID)
+	if err != nil {
+		return nil, nil // reaped or cleared by another node
+	}
+	defer dismutex.Unlock(ctx, nodeID, mid)
+
+	// the node may have been reaped, or come back, while the mutex was acquired
+	expiry, err := trdredis.ZScore(ctx, def.RedisAddressbookLeasesField, nodeID).Result()
+	if err == redis.Nil || (err == nil && int64(expiry) > time.Now().UnixMilli()) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	known, err := trdredis.HExists(ctx, def.RedisAddressbookNodesField, nodeID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !known { // the node registered no actor
+		return nil, trdredis.ZRem(ctx, def.RedisAddressbookLeasesField, nodeID).Err()
+	}
+
+	pipe := trdredis.Pipeline()
+	actors, err := clearNode(ctx, pipe, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	pipe.ZRem(ctx, def.RedisAddressbookLeasesField, nodeID)
+
+	down := core.NodeDown{Node: nodeID, Actors: actors, Time: time.Now()}
+	byt, err := json.Marshal(down)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := trdredis.Incr(ctx, def.RedisAddressbookDownSeqField).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe.ZAdd(ctx, def.RedisAddressbookDownField, redis.Z{Score: float64(seq), Member: string(byt)})
+	pipe.ZRemRangeByRank(ctx, def.RedisAddressbookDownField, 0, -NodeDownLogLimit-1)
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	log.WarnF("[braid.addressbook] node %v lease expired, reaped %v actors", nodeID, len(actors))
+	return &down, nil
+}
+
+// NodeDowns returns the nodes logged as down after the cursor
+func (ab *AddressBook) NodeDowns(ctx context.Context, cursor int64) ([]core.NodeDown, int64, error) {
+	if cursor < 0 {
+		seq, err := trdredis.Get(ctx, def.RedisAddressbookDownSeqField).Int64()
+		if err != nil && err != redis.Nil {
+			return nil, cursor, err
+		}
+		return nil, seq, nil
+	}
+
+	entries, err := trdredis.GetClient().ZRangeByScoreWithScores(ctx, def.RedisAddressbookDownField, &redis.ZRangeBy{
+		Min: fmt.Sprintf("(%d", cursor),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	downs := make([]core.NodeDown, 0, len(entries))
+	for _, entry := range entries {
+		var down core.NodeDown
+		if err := json.Unmarshal([]byte(entry.Member.(string)), &down); err != nil {
+			continue
+		}
+		downs = append(downs, down)
+		cursor = int64(entry.Score)
+	}
+
+	return downs, cursor, nil
+}
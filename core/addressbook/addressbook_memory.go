@@ -0,0 +1,475 @@
+package addressbook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pojol/braid/core"
+)
+
+// MemoryStore holds the records of the memory address books, the nodes of a process which share a store see each other
+type MemoryStore struct {
+	ids    map[string]core.AddressInfo
+	types  map[string]map[string]core.AddressInfo // actor type -> actor id -> address
+	groups map[string]map[string]core.AddressInfo // group -> actor id -> address
+
+	nodes       map[string]core.AddressInfo
+	nodeWeights map[string]int
+
+	leases  map[string]time.Time // node -> expiry of its lease
+	downs   []core.NodeDown      // the node down log, downs[i] has the cursor downSeq - len(downs) + i + 1
+	downSeq int64
+
+	transport     CacheTransport // the invalidations of the caches of the memory books
+	transportOnce sync.Once
+
+	sync.RWMutex
+}
+
+func (s *MemoryStore) cacheTransport() CacheTransport {
+	s.transportOnce.Do(func() {
+		s.transport = NewMemoryCacheTransport()
+	})
+	return s.transport
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		ids:         make(map[string]core.AddressInfo),
+		types:       make(map[string]map[string]core.AddressInfo),
+		groups:      make(map[string]map[string]core.AddressInfo),
+		nodes:       make(map[string]core.AddressInfo),
+		nodeWeights: make(map[string]int),
+		leases:      make(map[string]time.Time),
+	}
+}
+
+// MemoryAddressBook is an address book which lives in memory, for single-node deployments and tests.
+// It has the semantics of the redis AddressBook: type sets, node weights, wildcard picking and quantity limits
+type MemoryAddressBook struct {
+	NodeID string
+	Ip     string
+	Port   int
+
+	store *MemoryStore
+
+	// the local actors and the groups they joined, registered again if the node loses its lease
+	actors map[string]localActor
+	groups map[string]map[string]struct{} // actor id -> group names
+
+	leased bool // the node took its lease
+
+	sync.Mutex
+}
+
+// NewMemory creates the memory address book of a node, a nil store gives the node a store of its own
+func NewMemory(info core.AddressInfo, store *MemoryStore) *MemoryAddressBook {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return &MemoryAddressBook{
+		NodeID: info.Node,
+		Ip:     info.Ip,
+		Port:   info.Port,
+		store:  store,
+		actors: make(map[string]localActor),
+		groups: make(map[string]map[string]struct{}),
+	}
+}
+
+// Memory returns a builder of memory address books sharing store, see core.NodeWithAddressBook
+func Memory(store *MemoryStore) func(core.AddressInfo) core.IAddressBook {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return func(info core.AddressInfo) core.IAddressBook {
+		return NewMemory(info, store)
+	}
+}
+
+// InMemory reports whether the address book, or the address book wrapped by its cache, lives in memory.
+// A node with an in-memory address book does not need redis
+func InMemory(ab core.IAddressBook) bool {
+	switch b := ab.(type) {
+	case *MemoryAddressBook:
+		return true
+	case *Cache:
+		return InMemory(b.IAddressBook)
+	}
+	return false
+}
+
+func (ab *MemoryAddressBook) Register(ctx context.Context, ty, id string, weight int) error {
+	if id == "" || ty == "" {
+		return fmt.Errorf("actor id or type is empty")
+	}
+
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	if info, ok := s.ids[id]; ok && info.Node == ab.NodeID {
+		return fmt.Errorf("actor id %v already registered", id)
+	}
+
+	addr := core.AddressInfo{Node: ab.NodeID, ActorId: id, ActorTy: ty, Ip: ab.Ip, Port: ab.Port}
+
+	s.ids[id] = addr
+	if _, ok := s.types[ty]; !ok {
+		s.types[ty] = make(map[string]core.AddressInfo)
+	}
+	s.types[ty][id] = addr
+
+	s.nodes[ab.NodeID] = core.AddressInfo{Node: ab.NodeID, Ip: ab.Ip, Port: ab.Port}
+	s.nodeWeights[ab.NodeID] += weight
+
+	ab.Lock()
+	ab.actors[id] = localActor{ty: ty, weight: weight}
+	ab.Unlock()
+
+	return nil
+}
+
+func (ab *MemoryAddressBook) Unregister(ctx context.Context, id string, weight int) error {
+	if id == "" {
+		return fmt.Errorf("actor id or type is empty")
+	}
+
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	info, ok := s.ids[id]
+	if !ok {
+		return fmt.Errorf("address not found for id: %s", id)
+	}
+
+	delete(s.ids, id)
+	delete(s.types[info.ActorTy], id)
+
+	s.nodeWeights[ab.NodeID] -= weight
+
+	ab.Lock()
+	for group := range ab.groups[id] {
+		delete(s.groups[group], id)
+	}
+	delete(ab.groups, id)
+	delete(ab.actors, id)
+	ab.Unlock()
+
+	return nil
+}
+
+// GetByID get actor address by id
+func (ab *MemoryAddressBook) GetByID(ctx context.Context, id string) (core.AddressInfo, error) {
+	if id == "" {
+		return core.AddressInfo{}, fmt.Errorf("actor id or type is empty")
+	}
+
+	ab.store.RLock()
+	defer ab.store.RUnlock()
+
+	info, ok := ab.store.ids[id]
+	if !ok {
+		return core.AddressInfo{}, ErrUnknownActor
+	}
+
+	return info, nil
+}
+
+// GetByType get actor address by type
+func (ab *MemoryAddressBook) GetByType(ctx context.Context, actorType string) ([]core.AddressInfo, error) {
+	ab.store.RLock()
+	defer ab.store.RUnlock()
+
+	addresses := make([]core.AddressInfo, 0, len(ab.store.types[actorType]))
+	for _, addr := range ab.store.types[actorType] {
+		addresses = append(addresses, addr)
+	}
+
+	return addresses, nil
+}
+
+// JoinGroup adds a registered actor to a named group
+func (ab *MemoryAddressBook) JoinGroup(ctx context.Context, group, id string) error {
+	if group == "" || id == "" {
+		return fmt.Errorf("actor id or group is empty")
+	}
+
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	info, ok := s.ids[id]
+	if !ok {
+		return ErrUnknownActor
+	}
+
+	if _, ok := s.groups[group]; !ok {
+		s.groups[group] = make(map[string]core.AddressInfo)
+	}
+	s.groups[group][id] = info
+
+	ab.Lock()
+	if _, ok := ab.groups[id]; !ok {
+		ab.groups[id] = make(map[string]struct{})
+	}
+	ab.groups[id][group] = struct{}{}
+	ab.Unlock()
+
+	return nil
+}
+
+// LeaveGroup removes an actor from a named group
+func (ab *MemoryAddressBook) LeaveGroup(ctx context.Context, group, id string) error {
+	if group == "" || id == "" {
+		return fmt.Errorf("actor id or group is empty")
+	}
+
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.ids[id]; !ok {
+		return ErrUnknownActor
+	}
+	delete(s.groups[group], id)
+
+	ab.Lock()
+	delete(ab.groups[id], group)
+	ab.Unlock()
+
+	return nil
+}
+
+// GetGroup get the members of a named group
+func (ab *MemoryAddressBook) GetGroup(ctx context.Context, group string) ([]core.AddressInfo, error) {
+	ab.store.RLock()
+	defer ab.store.RUnlock()
+
+	members := make([]core.AddressInfo, 0, len(ab.store.groups[group]))
+	for _, addr := range ab.store.groups[group] {
+		members = append(members, addr)
+	}
+
+	return members, nil
+}
+
+// GetWildcardActor retrieves an actor address of the specified actorType, picked by the selector
+func (ab *MemoryAddressBook) GetWildcardActor(ctx context.Context, actorType string, selector core.ISelector) (core.AddressInfo, error) {
+	if selector == nil {
+		selector = NewSelector(SelectorLowestWeight)
+	}
+
+	s := ab.store
+	s.RLock()
+	defer s.RUnlock()
+
+	actors := s.types[actorType]
+	if len(actors) == 0 {
+		return core.AddressInfo{}, fmt.Errorf("no actors found for type %s", actorType)
+	}
+
+	picks := make([]core.AddressInfo, 0, len(actors))
+	for _, addr := range actors {
+		picks = append(picks, addr)
+	}
+	picks = sample(picks, selector.Sample())
+
+	weights := make(map[string]int, len(picks))
+	for _, addr := range picks {
+		weights[addr.Node] = s.nodeWeights[addr.Node]
+	}
+
+	return selector.Select(picks, weights), nil
+}
+
+func (ab *MemoryAddressBook) nodeID() string {
+	return ab.NodeID
+}
+
+func (ab *MemoryAddressBook) nodeWeights(ctx context.Context, nodes []string) (map[string]int, error) {
+	s := ab.store
+	s.RLock()
+	defer s.RUnlock()
+
+	weights := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		if _, ok := s.nodes[node]; ok {
+			weights[node] = s.nodeWeights[node]
+		}
+	}
+	return weights, nil
+}
+
+// GetLowWeightNodeForActor retrieves the node with the lowest weight
+func (ab *MemoryAddressBook) GetLowWeightNodeForActor(ctx context.Context, actorType string) (core.AddressInfo, error) {
+	s := ab.store
+	s.RLock()
+	defer s.RUnlock()
+
+	if len(s.nodes) == 0 {
+		return core.AddressInfo{}, fmt.Errorf("no nodes found")
+	}
+
+	var selectedNode core.AddressInfo
+	lowestWeight := int(^uint(0) >> 1) // Max int value
+
+	for nodeID, info := range s.nodes {
+		if weight := s.nodeWeights[nodeID]; weight < lowestWeight {
+			lowestWeight = weight
+			selectedNode = info
+		}
+	}
+
+	return selectedNode, nil
+}
+
+// GetActorTypeCount retrieves the count of registered actors of the specified type
+func (ab *MemoryAddressBook) GetActorTypeCount(ctx context.Context, actorType string) (int64, error) {
+	ab.store.RLock()
+	defer ab.store.RUnlock()
+
+	return int64(len(ab.store.types[actorType])), nil
+}
+
+// Clear removes the actors and the records of this node
+func (ab *MemoryAddressBook) Clear(ctx context.Context) error {
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	for id, info := range s.ids {
+		if info.Node == ab.NodeID {
+			delete(s.ids, id)
+			delete(s.types[info.ActorTy], id)
+		}
+	}
+
+	ab.Lock()
+	for id, groups := range ab.groups {
+		for group := range groups {
+			delete(s.groups[group], id)
+		}
+	}
+	// the records are gone, a later lease has nothing to restore
+	ab.actors = make(map[string]localActor)
+	ab.groups = make(map[string]map[string]struct{})
+	ab.leased = false
+	ab.Unlock()
+
+	delete(s.nodes, ab.NodeID)
+	delete(s.nodeWeights, ab.NodeID)
+	delete(s.leases, ab.NodeID)
+
+	return nil
+}
+
+// KeepAlive refreshes the lease of the node, the records of a node reaped while it was alive are registered again
+func (ab *MemoryAddressBook) KeepAlive(ctx context.Context, ttl time.Duration) error {
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	ab.Lock()
+	defer ab.Unlock()
+
+	_, ok := s.leases[ab.NodeID]
+	s.leases[ab.NodeID] = time.Now().Add(ttl)
+	if ok || !ab.leased {
+		ab.leased = true
+		return nil
+	}
+
+	for id, actor := range ab.actors {
+		if _, ok := s.ids[id]; ok {
+			continue // registered on another node in the meantime
+		}
+
+		addr := core.AddressInfo{Node: ab.NodeID, ActorId: id, ActorTy: actor.ty, Ip: ab.Ip, Port: ab.Port}
+		s.ids[id] = addr
+		if _, ok := s.types[actor.ty]; !ok {
+			s.types[actor.ty] = make(map[string]core.AddressInfo)
+		}
+		s.types[actor.ty][id] = addr
+		s.nodes[ab.NodeID] = core.AddressInfo{Node: ab.NodeID, Ip: ab.Ip, Port: ab.Port}
+		s.nodeWeights[ab.NodeID] += actor.weight
+
+		for group := range ab.groups[id] {
+			if _, ok := s.groups[group]; !ok {
+				s.groups[group] = make(map[string]core.AddressInfo)
+			}
+			s.groups[group][id] = addr
+		}
+	}
+
+	return core.ErrLeaseLost
+}
+
+// Reap removes the records of the nodes whose lease expired, the nodes which never took a lease are left alone
+func (ab *MemoryAddressBook) Reap(ctx context.Context) ([]core.NodeDown, error) {
+	s := ab.store
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	var downs []core.NodeDown
+
+	for nodeID, expiry := range s.leases {
+		if nodeID == ab.NodeID || now.Before(expiry) {
+			continue
+		}
+		if _, ok := s.nodes[nodeID]; !ok { // the node registered no actor
+			delete(s.leases, nodeID)
+			continue
+		}
+
+		down := core.NodeDown{Node: nodeID, Time: now}
+		for id, info := range s.ids {
+			if info.Node != nodeID {
+				continue
+			}
+			down.Actors = append(down.Actors, info)
+			delete(s.ids, id)
+			delete(s.types[info.ActorTy], id)
+			for _, members := range s.groups {
+				delete(members, id)
+			}
+		}
+
+		delete(s.nodes, nodeID)
+		delete(s.nodeWeights, nodeID)
+		delete(s.leases, nodeID)
+
+		s.downs = append(s.downs, down)
+		s.downSeq++
+		if len(s.downs) > NodeDownLogLimit {
+			s.downs = s.downs[len(s.downs)-NodeDownLogLimit:]
+		}
+		downs = append(downs, down)
+	}
+
+	return downs, nil
+}
+
+// NodeDowns returns the nodes logged as down after the cursor
+func (ab *MemoryAddressBook) NodeDowns(ctx context.Context, cursor int64) ([]core.NodeDown, int64, error) {
+	s := ab.store
+	s.RLock()
+	defer s.RUnlock()
+
+	if cursor < 0 || cursor >= s.downSeq {
+		return nil, s.downSeq, nil
+	}
+
+	first := s.downSeq - int64(len(s.downs)) // the cursor before downs[0]
+	if cursor < first {
+		cursor = first
+	}
+
+	return append([]core.NodeDown{}, s.downs[cursor-first:]...), s.downSeq, nil
+}
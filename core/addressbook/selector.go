@@ -0,0 +1,90 @@
+package addressbook
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+
+	"github.com/pojol/braid/core"
+)
+
+// The names of the selectors, the values of core.SelectorOption
+const (
+	SelectorLowestWeight = "lowest_weight"
+	SelectorPowerOfTwo   = "p2c"
+	SelectorRoundRobin   = "round_robin"
+	SelectorRandom       = "random"
+)
+
+// NewSelector builds the selector of a name, an empty or unknown name builds the lowest weight selector
+func NewSelector(name string) core.ISelector {
+	switch name {
+	case SelectorPowerOfTwo:
+		return &powerOfTwoSelector{}
+	case SelectorRoundRobin:
+		return &roundRobinSelector{}
+	case SelectorRandom:
+		return &randomSelector{}
+	default:
+		return &lowestWeightSelector{}
+	}
+}
+
+// lowestWeightSelector picks among PickLimit random actors the one on the node with the lowest weight
+type lowestWeightSelector struct{}
+
+func (s *lowestWeightSelector) Sample() int { return PickLimit }
+
+func (s *lowestWeightSelector) Select(candidates []core.AddressInfo, weights map[string]int) core.AddressInfo {
+	lowest := candidates[0]
+	for _, addr := range candidates[1:] {
+		if weights[addr.Node] < weights[lowest.Node] {
+			lowest = addr
+		}
+	}
+	return lowest
+}
+
+// powerOfTwoSelector picks the lighter of two random actors, it spreads the load better than the lowest weight
+// when the weights are stale, as every node does not rush to the same one
+type powerOfTwoSelector struct{}
+
+func (s *powerOfTwoSelector) Sample() int { return 2 }
+
+func (s *powerOfTwoSelector) Select(candidates []core.AddressInfo, weights map[string]int) core.AddressInfo {
+	if len(candidates) > 1 && weights[candidates[1].Node] < weights[candidates[0].Node] {
+		return candidates[1]
+	}
+	return candidates[0]
+}
+
+// roundRobinSelector takes the actors of the type in turns, in the order of their ids
+type roundRobinSelector struct {
+	next uint64
+}
+
+func (s *roundRobinSelector) Sample() int { return 0 }
+
+func (s *roundRobinSelector) Select(candidates []core.AddressInfo, weights map[string]int) core.AddressInfo {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ActorId < candidates[j].ActorId })
+	n := atomic.AddUint64(&s.next, 1) - 1
+	return candidates[n%uint64(len(candidates))]
+}
+
+// randomSelector picks any actor of the type
+type randomSelector struct{}
+
+func (s *randomSelector) Sample() int { return 1 }
+
+func (s *randomSelector) Select(candidates []core.AddressInfo, weights map[string]int) core.AddressInfo {
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// sample returns n random candidates, or all of them shuffled when n is 0
+func sample(addrs []core.AddressInfo, n int) []core.AddressInfo {
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	if n > 0 && len(addrs) > n {
+		addrs = addrs[:n]
+	}
+	return addrs
+}
@@ -0,0 +1,59 @@
+package core
+
+import "time"
+
+// CallParm per-call options of Call, Send and ReenterCall
+type CallParm struct {
+	// Timeout bounds each attempt of the call, 0 keeps the default of the system (5s for Call, 30s for ReenterCall).
+	// The deadline travels with the request, the callee skips a request its caller already gave up on
+	Timeout time.Duration
+
+	// Retries is the number of times a failed call is attempted again, only use it for idempotent events
+	Retries int
+
+	// Backoff is the wait before the first retry, it doubles on each retry
+	Backoff time.Duration
+
+	// Priority of the request, see msg.PriorityHigh
+	Priority int32
+
+	// NoTrace skips the trace span of the call
+	NoTrace bool
+}
+
+type CallOption func(*CallParm)
+
+func CallWithTimeout(timeout time.Duration) CallOption {
+	return func(p *CallParm) {
+		p.Timeout = timeout
+	}
+}
+
+// CallWithRetry retries a failed call up to retries times, waiting backoff, 2*backoff, 4*backoff ... in between
+func CallWithRetry(retries int, backoff time.Duration) CallOption {
+	return func(p *CallParm) {
+		p.Retries = retries
+		p.Backoff = backoff
+	}
+}
+
+func CallWithPriority(priority int32) CallOption {
+	return func(p *CallParm) {
+		p.Priority = priority
+	}
+}
+
+func CallWithoutTrace() CallOption {
+	return func(p *CallParm) {
+		p.NoTrace = true
+	}
+}
+
+// BuildCallParm applies the options to an empty CallParm
+func BuildCallParm(opts ...CallOption) CallParm {
+	p := CallParm{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCallCycle is returned by a synchronous Call which would wait on an actor already waiting for it,
+// the error names the cycle, e.g. "actor-a -> actor-b -> actor-a"
+var ErrCallCycle = errors.New("[braid.actor] call cycle")
+
+// CallCycle returns an ErrCallCycle naming the cycle if id is already blocked in the call path
+//
+//	path is Header.CallPath, the actors blocked in the synchronous calls which led to the message, oldest first
+func CallCycle(path []string, id string) error {
+	for i, caller := range path {
+		if caller == id {
+			cycle := append(append([]string{}, path[i:]...), id)
+			return fmt.Errorf("%w: %v", ErrCallCycle, strings.Join(cycle, " -> "))
+		}
+	}
+	return nil
+}
+
+// WaitFor is an edge of the wait-for graph, Caller is blocked in a synchronous call to Callee since Since
+type WaitFor struct {
+	Caller string
+	Callee string
+	Event  string
+	Since  time.Time
+}
+
+// DumpWaitForGraph formats the edges one per line, the longest waits first
+func DumpWaitForGraph(edges []WaitFor) string {
+	sorted := append([]WaitFor{}, edges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Since.Before(sorted[j].Since)
+	})
+
+	var sb strings.Builder
+	for _, e := range sorted {
+		fmt.Fprintf(&sb, "%v -> %v event %v waiting %v\n", e.Caller, e.Callee, e.Event, time.Since(e.Since).Round(time.Millisecond))
+	}
+	return sb.String()
+}
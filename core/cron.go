@@ -0,0 +1,39 @@
+package core
+
+import "time"
+
+// CronMisfirePolicy decides what happens to a cron fire that could not run on time
+// (the actor was busy with other messages, or the process was suspended)
+type CronMisfirePolicy int
+
+const (
+	// CronMisfireFireOnce runs the callback once for all the missed fires, then resumes the schedule
+	CronMisfireFireOnce CronMisfirePolicy = iota
+
+	// CronMisfireSkip drops the missed fires and waits for the next scheduled time
+	CronMisfireSkip
+)
+
+// DefaultCronMisfireThreshold is how late a cron fire can run before it counts as missed
+const DefaultCronMisfireThreshold = time.Second
+
+type CronParm struct {
+	Misfire CronMisfirePolicy
+
+	// MisfireThreshold is how late a fire can run before the misfire policy applies
+	MisfireThreshold time.Duration
+}
+
+type CronOption func(*CronParm)
+
+func CronWithMisfire(policy CronMisfirePolicy) CronOption {
+	return func(cp *CronParm) {
+		cp.Misfire = policy
+	}
+}
+
+func CronWithMisfireThreshold(threshold time.Duration) CronOption {
+	return func(cp *CronParm) {
+		cp.MisfireThreshold = threshold
+	}
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pojol/braid/router"
+	"github.com/pojol/braid/router/msg"
+)
+
+// DeadLetterReason tells why a message could not be delivered or handled
+type DeadLetterReason string
+
+const (
+	// DeadLetterUnknownEvent the target actor has no handler for the event
+	DeadLetterUnknownEvent DeadLetterReason = "unknown_event"
+
+	// DeadLetterActorClosed the target actor was closed when the message arrived
+	DeadLetterActorClosed DeadLetterReason = "actor_closed"
+
+	// DeadLetterMailboxOverflow the message was rejected or dropped by the mailbox overflow policy
+	DeadLetterMailboxOverflow DeadLetterReason = "mailbox_overflow"
+
+	// DeadLetterHandlerError the handler of the event returned an error
+	DeadLetterHandlerError DeadLetterReason = "handler_error"
+
+	// DeadLetterRemoteFailure the message (sent or called) could not be routed to the node of the target actor
+	DeadLetterRemoteFailure DeadLetterReason = "remote_failure"
+
+	// DeadLetterExpired the deadline of the message passed before it was handled, its caller gave up
+	DeadLetterExpired DeadLetterReason = "expired"
+)
+
+// DeadLetterEvent is the event of the messages sent by DeadLetterActorSink, the body is an encoded DeadLetter
+const DeadLetterEvent = "braid.deadletter"
+
+// DeadLetter is a message which could not be delivered or handled, with its original headers
+type DeadLetter struct {
+	Reason DeadLetterReason
+	Err    string
+	Node   string // node which dropped the message
+	Time   time.Time
+
+	Header *router.Header
+	Body   []byte
+}
+
+// Encode encodes the dead letter as json, so that it can be read by other tools
+func (dl DeadLetter) Encode() ([]byte, error) {
+	return json.Marshal(dl)
+}
+
+func DecodeDeadLetter(byt []byte) (DeadLetter, error) {
+	var dl DeadLetter
+	err := json.Unmarshal(byt, &dl)
+	return dl, err
+}
+
+// ReplayDeadLetter sends the message again to its original target, with a new message id
+func ReplayDeadLetter(sys ISystem, dl DeadLetter) error {
+	mw := msg.NewBuilder(context.TODO()).WithReqBody(dl.Body).Build()
+
+	header := *dl.Header
+	header.ID = mw.Req.Header.ID
+	header.Err = ""
+	mw.Req.Header = &header
+
+	return sys.Send(header.TargetActorID, header.TargetActorType, header.Event, mw)
+}
+
+// IDeadLetterSink receives the dead letters of a node
+//
+//	Receive is called on the goroutine which dropped the message (often an actor goroutine), it must not block
+type IDeadLetterSink interface {
+	Receive(sys ISystem, dl DeadLetter)
+}
+
+// IDeadLetterSinkCloser is implemented by the sinks which run a worker, Close is called when the system exits
+type IDeadLetterSinkCloser interface {
+	Close()
+}
+
+// DeadLetterFunc is a callback sink
+type DeadLetterFunc func(dl DeadLetter)
+
+func (f DeadLetterFunc) Receive(sys ISystem, dl DeadLetter) {
+	f(dl)
+}
+
+// DefaultDeadLetterQueueSize is the number of dead letters a sink queues while it is delivering
+const DefaultDeadLetterQueueSize = 1024
+
+// deadLetterQueue hands the encoded dead letters over to a single worker which delivers them,
+// the letters which arrive while the queue is full, or once it is closed, are dropped and counted
+type deadLetterQueue struct {
+	sync.Mutex
+	letters chan []byte
+	closed  bool
+	dropped uint64
+}
+
+func (q *deadLetterQueue) push(size int, byt []byte, deliver func(byt []byte)) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.closed {
+		atomic.AddUint64(&q.dropped, 1)
+		return
+	}
+
+	if q.letters == nil {
+		if size <= 0 {
+			size = DefaultDeadLetterQueueSize
+		}
+		q.letters = make(chan []byte, size)
+		go func(letters chan []byte) {
+			for byt := range letters {
+				deliver(byt)
+			}
+		}(q.letters)
+	}
+
+	select {
+	case q.letters <- byt:
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+	}
+}
+
+// close stops the worker once it delivered the queued letters
+func (q *deadLetterQueue) close() {
+	q.Lock()
+	defer q.Unlock()
+
+	if !q.closed && q.letters != nil {
+		close(q.letters)
+	}
+	q.closed = true
+}
+
+// DeadLetterActorSink sends the dead letters to a dedicated actor as DeadLetterEvent messages
+//
+//	the letters are sent one at a time by a worker of the sink, up to QueueSize letters wait for it, the others are dropped
+type DeadLetterActorSink struct {
+	ActorID   string
+	ActorTy   string
+	QueueSize int // DefaultDeadLetterQueueSize if 0
+
+	queue deadLetterQueue
+}
+
+func (s *DeadLetterActorSink) Receive(sys ISystem, dl DeadLetter) {
+	byt, err := dl.Encode()
+	if err != nil {
+		return
+	}
+
+	s.queue.push(s.QueueSize, byt, func(byt []byte) {
+		sys.Send(s.ActorID, s.ActorTy, DeadLetterEvent, msg.NewBuilder(context.TODO()).WithReqBody(byt).Build())
+	})
+}
+
+// Dropped returns the number of dead letters dropped while the queue of the sink was full or closed
+func (s *DeadLetterActorSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.queue.dropped)
+}
+
+// Close stops the worker of the sink, the letters received afterwards are dropped
+func (s *DeadLetterActorSink) Close() {
+	s.queue.close()
+}
+
+// DeadLetterTopicSink publishes the dead letters to a pubsub topic as DeadLetterEvent messages
+//
+//	the letters are published one at a time by a worker of the sink, up to QueueSize letters wait for it, the others are dropped
+type DeadLetterTopicSink struct {
+	Topic     string
+	QueueSize int // DefaultDeadLetterQueueSize if 0
+
+	queue deadLetterQueue
+}
+
+func (s *DeadLetterTopicSink) Receive(sys ISystem, dl DeadLetter) {
+	byt, err := dl.Encode()
+	if err != nil {
+		return
+	}
+
+	s.queue.push(s.QueueSize, byt, func(byt []byte) {
+		sys.Pub(s.Topic, DeadLetterEvent, byt)
+	})
+}
+
+// Dropped returns the number of dead letters dropped while the queue of the sink was full or closed
+func (s *DeadLetterTopicSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.queue.dropped)
+}
+
+// Close stops the worker of the sink, the letters received afterwards are dropped
+func (s *DeadLetterTopicSink) Close() {
+	s.queue.close()
+}
+
+// DeadLetterWithReason matches the dead letters of a reason
+func DeadLetterWithReason(reason DeadLetterReason) func(DeadLetter) bool {
+	return func(dl DeadLetter) bool {
+		return dl.Reason == reason
+	}
+}
+
+// DeadLetterWithActor matches the dead letters sent to an actor
+func DeadLetterWithActor(actorID string) func(DeadLetter) bool {
+	return func(dl DeadLetter) bool {
+		return dl.Header != nil && dl.Header.TargetActorID == actorID
+	}
+}
+
+// DeadLetterBuffer keeps the latest dead letters in memory for inspection and replay
+type DeadLetterBuffer struct {
+	sync.Mutex
+	size    int
+	letters []DeadLetter
+}
+
+func NewDeadLetterBuffer(size int) *DeadLetterBuffer {
+	return &DeadLetterBuffer{size: size}
+}
+
+func (b *DeadLetterBuffer) Receive(sys ISystem, dl DeadLetter) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.letters = append(b.letters, dl)
+	if over := len(b.letters) - b.size; b.size > 0 && over > 0 {
+		b.letters = append(b.letters[:0], b.letters[over:]...)
+	}
+}
+
+// List returns the buffered dead letters matching the filter (nil matches all), oldest first
+func (b *DeadLetterBuffer) List(filter func(DeadLetter) bool) []DeadLetter {
+	b.Lock()
+	defer b.Unlock()
+
+	var letters []DeadLetter
+	for _, dl := range b.letters {
+		if filter == nil || filter(dl) {
+			letters = append(letters, dl)
+		}
+	}
+	return letters
+}
+
+// Replay removes the matching dead letters from the buffer and sends them again,
+// a message which fails again comes back to the buffer as a new dead letter
+//
+//	returns the number of messages sent and the first send error
+func (b *DeadLetterBuffer) Replay(sys ISystem, filter func(DeadLetter) bool) (int, error) {
+	b.Lock()
+	var replay []DeadLetter
+	kept := b.letters[:0]
+	for _, dl := range b.letters {
+		if filter == nil || filter(dl) {
+			replay = append(replay, dl)
+		} else {
+			kept = append(kept, dl)
+		}
+	}
+	b.letters = kept
+	b.Unlock()
+
+	var firstErr error
+	sent := 0
+	for _, dl := range replay {
+		if err := ReplayDeadLetter(sys, dl); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sent++
+	}
+
+	return sent, firstErr
+}
@@ -0,0 +1,11 @@
+package core
+
+// IDispatcher runs actor turns on a shared pool of workers, instead of one goroutine per actor
+//
+//	an actor with pending work (messages, timers, reentry callbacks) is scheduled once, its turns never overlap,
+//	so the handlers, timers and callbacks of an actor never run concurrently. See actor.NewDispatcher
+type IDispatcher interface {
+	// Schedule queues a turn of an actor, a turn handles at most throughput items and returns true
+	// if the actor still has pending work, it is then queued again behind the other scheduled actors
+	Schedule(turn func(throughput int) bool)
+}
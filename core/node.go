@@ -1,6 +1,10 @@
 package core
 
-import "github.com/pojol/braid/lib/tracer"
+import (
+	"time"
+
+	"github.com/pojol/braid/lib/tracer"
+)
 
 /*
 	init - 初始化进程
@@ -27,6 +31,35 @@ type NodeParm struct {
 
 	Loader  IActorLoader
 	Factory IActorFactory
+
+	// Supervisor handles the failures escalated by actors without a parent, defaults to stopping the actor
+	Supervisor ISupervisor
+
+	// DeadLetterSinks receive the messages which could not be delivered or handled, defaults to logging them
+	DeadLetterSinks []IDeadLetterSink
+
+	// CallGraph records the wait-for graph of the synchronous calls made by actors, for debugging deadlocks.
+	// The graph is logged when a call cycle is detected or a call times out
+	CallGraph bool
+
+	// AddressBook builds the address book of the node from its address, defaults to the redis address book
+	AddressBook func(node AddressInfo) IAddressBook
+
+	// Lease is the ttl of the lease of the node in the address book (10s by default), the heartbeat refreshes it
+	// every Lease/3. The records of a node whose lease expired are reaped by the other nodes
+	Lease time.Duration
+
+	// NodeDown is called on every node once a node was declared dead and reaped
+	NodeDown func(NodeDown)
+
+	// AddressBookCache caches the id and type lookups of the address book on the node, nil disables the cache
+	AddressBookCache *AddressBookCacheParm
+}
+
+// AddressBookCacheParm sizes the node-local cache of the address book
+type AddressBookCacheParm struct {
+	Size int           // entries of each lookup cache
+	TTL  time.Duration // age after which an entry is fetched again, in case an invalidation was missed
 }
 
 type NodeOption func(*NodeParm)
@@ -75,6 +108,54 @@ func NodeWithPort(port int) NodeOption {
 	}
 }
 
+func NodeWithSupervisor(s ISupervisor) NodeOption {
+	return func(np *NodeParm) {
+		np.Supervisor = s
+	}
+}
+
+// NodeWithDeadLetterSink adds a sink for the dead letters of this node
+func NodeWithDeadLetterSink(sink IDeadLetterSink) NodeOption {
+	return func(np *NodeParm) {
+		np.DeadLetterSinks = append(np.DeadLetterSinks, sink)
+	}
+}
+
+// NodeWithCallGraph records the wait-for graph of the synchronous calls, see ISystem.WaitForGraph
+func NodeWithCallGraph() NodeOption {
+	return func(np *NodeParm) {
+		np.CallGraph = true
+	}
+}
+
+// NodeWithAddressBook replaces the redis address book of the node, e.g. addressbook.Memory for single-node deployments and tests
+func NodeWithAddressBook(build func(node AddressInfo) IAddressBook) NodeOption {
+	return func(np *NodeParm) {
+		np.AddressBook = build
+	}
+}
+
+// NodeWithLease sets the ttl of the lease of the node, see NodeParm.Lease
+func NodeWithLease(ttl time.Duration) NodeOption {
+	return func(np *NodeParm) {
+		np.Lease = ttl
+	}
+}
+
+// NodeWithNodeDown registers the callback of the node down events
+func NodeWithNodeDown(f func(NodeDown)) NodeOption {
+	return func(np *NodeParm) {
+		np.NodeDown = f
+	}
+}
+
+// NodeWithAddressBookCache caches the lookups of the address book on the node, 0 uses the default size and ttl
+func NodeWithAddressBookCache(size int, ttl time.Duration) NodeOption {
+	return func(np *NodeParm) {
+		np.AddressBookCache = &AddressBookCacheParm{Size: size, TTL: ttl}
+	}
+}
+
 func NodeWithTracer(t tracer.ITracer) NodeOption {
 	return func(np *NodeParm) {
 		np.Tracer = t
@@ -30,7 +30,7 @@ func BuildProcessWithOption(opts ...core.NodeOption) core.INode {
 	}
 
 	pcs = &process{
-		sys: buildSystemWithOption(p.ID, p.Ip, p.Port, p.Loader, p.Factory, p.Tracer),
+		sys: buildSystemWithOption(p),
 		p:   p,
 	}
 
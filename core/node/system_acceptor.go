@@ -5,6 +5,7 @@ import (
 	fmt "fmt"
 	"runtime"
 	"strconv"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/pojol/braid/core"
@@ -12,7 +13,6 @@ import (
 	"github.com/pojol/braid/lib/log"
 	"github.com/pojol/braid/lib/span"
 	"github.com/pojol/braid/lib/tracer"
-	"github.com/pojol/braid/lib/warpwaitgroup"
 	"github.com/pojol/braid/router"
 	"github.com/pojol/braid/router/msg"
 
@@ -94,19 +94,42 @@ func (acceptor *Acceptor) Exit() {
 func (s *listen) Routing(ctx context.Context, req *router.RouteReq) (*router.RouteRes, error) {
 	res := &router.RouteRes{}
 
-	ctx = context.WithValue(ctx, msg.WaitGroupKey{}, &warpwaitgroup.WrapWaitGroup{})
+	// the caller gives up at the deadline of the request (the clocks of the nodes are assumed in sync),
+	// the handler sees it on the context of the message
+	var opts []core.CallOption
+	if deadline := req.Msg.Header.Deadline; deadline != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, time.Unix(0, deadline))
+		defer cancel()
+
+		if remaining := time.Until(time.Unix(0, deadline)); remaining > 0 {
+			opts = append(opts, core.CallWithTimeout(remaining))
+		}
+	}
 
 	routermsg := msg.NewBuilder(ctx).Build()
 	routermsg.Req = req.Msg
 	routermsg.Req.Header.PrevActorType = "GrpcAcceptor"
 
+	// multicast messages are fanned out to the actors of this node only
+	if ns, ok := s.sys.(*NormalSystem); ok && isMulticast(req.Msg.Header.TargetActorID) {
+		err := ns.localMulticast(req.Msg.Header.TargetActorID, req.Msg.Header.TargetActorType, routermsg, req.Msg.Header.Targets)
+		if err != nil {
+			log.InfoF("listen routing multicast %v err %v", req.Msg.Header.Event, err.Error())
+		}
+		return res, nil
+	}
+
 	err := s.sys.Call(
 		req.Msg.Header.TargetActorID,
 		req.Msg.Header.TargetActorType,
-		req.Msg.Header.Event, routermsg)
+		req.Msg.Header.Event, routermsg, opts...)
 
 	if err != nil {
 		log.InfoF("listen routing %v err %v", req.Msg.Header.Event, err.Error())
+		if routermsg.Res != nil && routermsg.Res.Header != nil && routermsg.Res.Header.Err == "" {
+			routermsg.Res.Header.Err = err.Error()
+		}
 	}
 
 	res.Msg = routermsg.Res
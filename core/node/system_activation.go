@@ -0,0 +1,132 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/dismutex"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+// activatable checks whether unknown actors of this type are created on demand
+func (sys *NormalSystem) activatable(actorType string) bool {
+	ac := sys.factory.Get(actorType)
+	return ac != nil && ac.ActivateOnDemand
+}
+
+// activate creates an unknown actor through the loader placement and waits until it is registered
+//
+//	the activation lock makes sure only one node in the cluster picks the actor,
+//	the others wait for it to show up in the address book.
+//	returns the local actor if it was placed on the current node
+func (sys *NormalSystem) activate(ctx context.Context, id, actorType string) (core.IActor, core.AddressInfo, error) {
+	unlock, err := sys.lockActivation(ctx, id)
+	if err == nil {
+		defer unlock()
+
+		// another node may have activated the actor while we were waiting for the lock
+		info, err := sys.addressbook.GetByID(ctx, id)
+		if err == nil {
+			return sys.activated(info)
+		}
+		if !errors.Is(err, core.ErrUnknownActor) {
+			return nil, core.AddressInfo{}, err
+		}
+
+		log.InfoF("braid.system activate actor %v ty %v on demand", id, actorType)
+		if err := sys.Loader(actorType).WithID(id).Picker(ctx); err != nil {
+			return nil, core.AddressInfo{}, fmt.Errorf("braid.system activate actor %v pick err %w", id, err)
+		}
+	} else if !errors.Is(err, dismutex.ErrFailed) {
+		return nil, core.AddressInfo{}, fmt.Errorf("braid.system activate actor %v lock err %w", id, err)
+	}
+
+	info, err := sys.waitActivated(ctx, id)
+	if err != nil {
+		return nil, core.AddressInfo{}, err
+	}
+	return sys.activated(info)
+}
+
+// memoryActivations are the activation locks of the nodes with an in-memory address book,
+// they share the process, actor id -> struct{}
+var memoryActivations sync.Map
+
+// lockActivation takes the activation lock of the actor, dismutex.ErrFailed if another node holds it
+func (sys *NormalSystem) lockActivation(ctx context.Context, id string) (func(), error) {
+	if !sys.redis {
+		if _, held := memoryActivations.LoadOrStore(id, struct{}{}); held {
+			return nil, dismutex.ErrFailed
+		}
+		return func() { memoryActivations.Delete(id) }, nil
+	}
+
+	token := def.RedisActivationLockField + id
+	mid, err := dismutex.Lock(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return func() { dismutex.Unlock(ctx, token, mid) }, nil
+}
+
+// waitActivated polls the address book until the actor is registered or the call timeout expires
+func (sys *NormalSystem) waitActivated(ctx context.Context, id string) (core.AddressInfo, error) {
+	timeout := time.After(sys.callTimeout)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		info, err := sys.addressbook.GetByID(ctx, id)
+		if err == nil {
+			return info, nil
+		}
+		if !errors.Is(err, core.ErrUnknownActor) {
+			return core.AddressInfo{}, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return core.AddressInfo{}, fmt.Errorf("braid.system activate actor %v timeout %w", id, core.ErrUnknownActor)
+		case <-ctx.Done():
+			return core.AddressInfo{}, fmt.Errorf("braid.system activate actor %v %w", id, ctx.Err())
+		}
+	}
+}
+
+func (sys *NormalSystem) activated(info core.AddressInfo) (core.IActor, core.AddressInfo, error) {
+	if info.Node != sys.nodeID {
+		return nil, info, nil
+	}
+
+	sys.RLock()
+	actor, ok := sys.actoridmap[info.ActorId]
+	sys.RUnlock()
+	if !ok {
+		return nil, info, fmt.Errorf("braid.system activate actor %v not found on local node", info.ActorId)
+	}
+
+	return actor, info, nil
+}
+
+// activateSend holds a message sent to an unknown actor and delivers it once the actor has been activated
+func (sys *NormalSystem) activateSend(id, actorType string, mw *msg.Wrapper) {
+	actor, info, err := sys.activate(mw.Ctx, id, actorType)
+	if err != nil {
+		sys.DeadLetter(mw, core.DeadLetterRemoteFailure, err)
+	} else if actor != nil {
+		err = actor.Received(mw)
+	} else {
+		err = sys.handleRemoteSend(info, mw)
+	}
+
+	if err != nil {
+		log.WarnF("braid.system send to activated actor %v ty %v event %v err %v", id, actorType, mw.Req.Header.Event, err)
+	}
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+// waitForGraph records the synchronous calls in flight from the actors of this node
+type waitForGraph struct {
+	sync.Mutex
+	seq   uint64
+	edges map[uint64]core.WaitFor
+}
+
+func (g *waitForGraph) enter(caller, callee, event string) uint64 {
+	g.Lock()
+	defer g.Unlock()
+
+	g.seq++
+	g.edges[g.seq] = core.WaitFor{Caller: caller, Callee: callee, Event: event, Since: time.Now()}
+	return g.seq
+}
+
+func (g *waitForGraph) leave(seq uint64) {
+	g.Lock()
+	delete(g.edges, seq)
+	g.Unlock()
+}
+
+func (g *waitForGraph) snapshot() []core.WaitFor {
+	g.Lock()
+	defer g.Unlock()
+
+	edges := make([]core.WaitFor, 0, len(g.edges))
+	for _, e := range g.edges {
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+func (sys *NormalSystem) WaitForGraph() []core.WaitFor {
+	if sys.waits == nil {
+		return nil
+	}
+	return sys.waits.snapshot()
+}
+
+// traceCall records a call made by an actor in the wait-for graph while it is in flight,
+// the graph is logged if the call runs into a cycle or times out
+func (sys *NormalSystem) traceCall(idOrSymbol, actorType, event string, mw *msg.Wrapper, p core.CallParm) error {
+	path := mw.Req.Header.CallPath
+	seq := sys.waits.enter(path[len(path)-1], idOrSymbol, event)
+	defer sys.waits.leave(seq)
+
+	begin := time.Now()
+	err := sys.call(idOrSymbol, actorType, event, mw, p)
+
+	timeout := sys.callTimeout
+	if p.Timeout > 0 {
+		timeout = p.Timeout
+	}
+	if errors.Is(err, core.ErrCallCycle) || mw.Ctx.Err() != nil || time.Since(begin) >= timeout {
+		log.WarnF("braid.system call %v -> %v event %v err %v, wait-for graph:\n%v",
+			path[len(path)-1], idOrSymbol, event, err, core.DumpWaitForGraph(sys.waits.snapshot()))
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+// attempt runs a call (wait) or a send with its options
+//
+//	each attempt is bounded by the timeout of the options, its deadline is carried by the request header.
+//	A failed or timed out attempt is retried with an exponential backoff, a call cycle is never retried.
+//	Without options mw is delivered as is, the handler may still write it once the call returned (e.g. from a
+//	future continuation). Otherwise each attempt delivers its own copy of mw with its own context and header,
+//	mw may be in flight already (a handler which forwards its message) and is never written while the attempt
+//	runs. Without retries the copy shares the response of mw, with retries it has its own (the handler of a
+//	timed out attempt may still hold it). The response of the last attempt is copied back to mw
+func (sys *NormalSystem) attempt(mw *msg.Wrapper, p core.CallParm, wait bool, f func(amw *msg.Wrapper) error) error {
+	if p.Retries == 0 && p.Timeout == 0 && p.Priority == 0 {
+		mw.Req.Header.Deadline = 0
+		if deadline, ok := mw.Ctx.Deadline(); ok {
+			mw.Req.Header.Deadline = deadline.UnixNano()
+		}
+		return f(mw)
+	}
+
+	base := mw.Ctx
+	backoff := p.Backoff
+
+	for i := 0; ; i++ {
+		ctx := base
+		amw := cloneAttempt(mw)
+		if p.Retries == 0 {
+			amw.Res = mw.Res
+		}
+		if p.Priority != 0 {
+			amw.Req.Header.Priority = p.Priority
+		}
+
+		cancel := context.CancelFunc(func() {})
+		if p.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		}
+		amw.Ctx = ctx
+
+		amw.Req.Header.Deadline = 0
+		deadline, bounded := ctx.Deadline()
+		if bounded {
+			amw.Req.Header.Deadline = deadline.UnixNano()
+		}
+
+		err := f(amw)
+		timedOut := wait && p.Timeout > 0 && !time.Now().Before(deadline)
+
+		if wait {
+			cancel()
+		} else {
+			// a sent message is handled after Send returned, its context lives until the deadline
+			time.AfterFunc(p.Timeout, cancel)
+		}
+
+		if (err == nil && !timedOut) || i >= p.Retries || errors.Is(err, core.ErrCallCycle) {
+			mw.Res, mw.Err = amw.Res, amw.Err
+			return err
+		}
+
+		log.InfoF("braid.system %v event %v attempt %v failed err %v timed out %v, retry in %v",
+			amw.Req.Header.TargetActorID, amw.Req.Header.Event, i+1, err, timedOut, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-base.Done():
+			if err == nil {
+				err = base.Err()
+			}
+			mw.Err = amw.Err
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// cloneAttempt copies mw for an attempt, the slices of the header are copied too so that the edits of
+// an attempt (e.g. the call path stamped by the callee) do not leak into mw or the next attempt
+func cloneAttempt(mw *msg.Wrapper) *msg.Wrapper {
+	amw := msg.Clone(mw)
+
+	h := amw.Req.Header
+	h.CallPath = append([]string(nil), h.CallPath...)
+	h.Targets = append([]string(nil), h.Targets...)
+	h.Custom = append([]byte(nil), h.Custom...)
+
+	return amw
+}
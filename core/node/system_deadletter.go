@@ -0,0 +1,35 @@
+package node
+
+import (
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+func (sys *NormalSystem) DeadLetter(mw *msg.Wrapper, reason core.DeadLetterReason, err error) {
+	dl := core.DeadLetter{
+		Reason: reason,
+		Node:   sys.nodeID,
+		Time:   time.Now(),
+		Body:   mw.Req.Body,
+	}
+	if err != nil {
+		dl.Err = err.Error()
+	}
+
+	header := *mw.Req.Header
+	dl.Header = &header
+
+	// the dead letters of the dead letter sink are only logged, so that a failing sink can not loop
+	if len(sys.deadLetters) == 0 || header.Event == core.DeadLetterEvent {
+		log.WarnF("braid.system dead letter %v actor %v ty %v event %v err %v",
+			reason, header.TargetActorID, header.TargetActorType, header.Event, dl.Err)
+		return
+	}
+
+	for _, sink := range sys.deadLetters {
+		sink.Receive(sys, dl)
+	}
+}
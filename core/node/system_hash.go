@@ -0,0 +1,53 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/hashring"
+)
+
+// hashRing is the consistent-hash ring of an actor type, rebuilt when the actors of the type change
+type hashRing struct {
+	members string // sorted actor ids
+	ring    *hashring.Ring
+}
+
+// hashActor maps the routing key onto an actor of the type, see def.SymbolHash
+func (sys *NormalSystem) hashActor(ctx context.Context, actorType, key string) (core.AddressInfo, error) {
+	if key == "" {
+		return core.AddressInfo{}, fmt.Errorf("braid.system hash routing to %v without routing key", actorType)
+	}
+
+	addrs, err := sys.addressbook.GetByType(ctx, actorType)
+	if err != nil {
+		return core.AddressInfo{}, err
+	}
+
+	byID := make(map[string]core.AddressInfo, len(addrs))
+	ids := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		byID[addr.ActorId] = addr
+		ids = append(ids, addr.ActorId)
+	}
+	sort.Strings(ids)
+	members := strings.Join(ids, ",")
+
+	sys.hashMu.Lock()
+	hr, ok := sys.hashRings[actorType]
+	if !ok || hr.members != members {
+		hr = &hashRing{members: members, ring: hashring.New(0, ids...)}
+		sys.hashRings[actorType] = hr
+	}
+	sys.hashMu.Unlock()
+
+	id, ok := hr.ring.Get(key)
+	if !ok {
+		return core.AddressInfo{}, fmt.Errorf("no actors found for type %s", actorType)
+	}
+
+	return byID[id], nil
+}
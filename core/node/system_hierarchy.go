@@ -0,0 +1,78 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pojol/braid/core"
+)
+
+// adopt records a registering actor's parent and locality, the parent must be a registered local actor
+func (sys *NormalSystem) adopt(builder core.IActorBuilder) error {
+	sys.Lock()
+	defer sys.Unlock()
+
+	parent := builder.GetParent()
+	if parent != "" {
+		if _, ok := sys.actoridmap[parent]; !ok {
+			return fmt.Errorf("braid.system register actor %v err, parent %v is not registered on this node", builder.GetID(), parent)
+		}
+
+		if sys.children[parent] == nil {
+			sys.children[parent] = make(map[string]struct{})
+		}
+		sys.children[parent][builder.GetID()] = struct{}{}
+		sys.parents[builder.GetID()] = parent
+	}
+
+	if builder.GetLocal() {
+		sys.locals[builder.GetID()] = struct{}{}
+	}
+
+	return nil
+}
+
+// orphan removes an actor from the hierarchy, returns whether the actor was local only
+func (sys *NormalSystem) orphan(id string) bool {
+	sys.Lock()
+	defer sys.Unlock()
+
+	if parent, ok := sys.parents[id]; ok {
+		delete(sys.children[parent], id)
+		if len(sys.children[parent]) == 0 {
+			delete(sys.children, parent)
+		}
+		delete(sys.parents, id)
+	}
+
+	_, local := sys.locals[id]
+	delete(sys.locals, id)
+
+	return local
+}
+
+func (sys *NormalSystem) isLocal(id string) bool {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	_, ok := sys.locals[id]
+	return ok
+}
+
+func (sys *NormalSystem) Children(parentID string) []core.IActor {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	children := make([]core.IActor, 0, len(sys.children[parentID]))
+	for id := range sys.children[parentID] {
+		if actor, ok := sys.actoridmap[id]; ok {
+			children = append(children, actor)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].ID() < children[j].ID()
+	})
+
+	return children
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/pojol/braid/lib/log"
 	"github.com/pojol/braid/lib/pubsub"
 	"github.com/pojol/braid/lib/span"
+	"github.com/pojol/braid/lib/timewheel"
 	"github.com/pojol/braid/lib/tracer"
 	"github.com/pojol/braid/router"
 	"github.com/pojol/braid/router/msg"
@@ -22,37 +23,77 @@ import (
 )
 
 type NormalSystem struct {
-	addressbook *addressbook.AddressBook
+	addressbook core.IAddressBook
 	actoridmap  map[string]core.IActor
 	client      *grpc.Client
 	ps          *pubsub.Pubsub
+	wheel       *timewheel.TimingWheel
 	acceptor    *Acceptor
 	loader      core.IActorLoader
 	factory     core.IActorFactory
 
+	// actor hierarchy, child -> parent and parent -> children, and the actors kept out of the address book
+	parents  map[string]string
+	children map[string]map[string]struct{}
+	locals   map[string]struct{}
+
 	nodeID   string
 	nodeIP   string
 	nodePort int
 
 	callTimeout time.Duration // sync call timeout
 
-	trac tracer.ITracer
+	trac        tracer.ITracer
+	supervisor  core.ISupervisor
+	deadLetters []core.IDeadLetterSink
+
+	// reminders, watches and activation locks are kept in redis, unless the address book lives in memory
+	redis        bool
+	reminderStop chan struct{}
+
+	lease     time.Duration
+	nodeDown  func(core.NodeDown)
+	leaseStop chan struct{}
+	leaseDone sync.WaitGroup // the heartbeat and the reaper
+
+	waits *waitForGraph // set with NodeWithCallGraph
+
+	// consistent-hash rings of the actor types routed with def.SymbolHash
+	hashRings map[string]*hashRing
+	hashMu    sync.Mutex
+
+	// selectors of the wildcard calls of the actor types, see core.SelectorOption
+	selectors  map[string]core.ISelector
+	selectorMu sync.Mutex
 
 	sync.RWMutex
 }
 
 var ErrSelfCall = errors.New("cannot call self node through RPC")
 
-func buildSystemWithOption(nodId, nodeIp string, nodePort int, loader core.IActorLoader, factory core.IActorFactory, trac tracer.ITracer) core.ISystem {
+func buildSystemWithOption(p core.NodeParm) core.ISystem {
 	var err error
 
+	loader, factory, trac := p.Loader, p.Factory, p.Tracer
+
 	sys := &NormalSystem{
 		actoridmap:  make(map[string]core.IActor),
-		nodeID:      nodId,
-		nodeIP:      nodeIp,
-		nodePort:    nodePort,
+		parents:     make(map[string]string),
+		children:    make(map[string]map[string]struct{}),
+		locals:      make(map[string]struct{}),
+		nodeID:      p.ID,
+		nodeIP:      p.Ip,
+		nodePort:    p.Port,
 		trac:        trac,
+		supervisor:  p.Supervisor,
+		deadLetters: p.DeadLetterSinks,
 		callTimeout: time.Second * 5,
+		hashRings:   make(map[string]*hashRing),
+		selectors:   make(map[string]core.ISelector),
+	}
+
+	if p.CallGraph {
+		sys.waits = &waitForGraph{edges: make(map[uint64]core.WaitFor)}
 	}
 
 	if loader == nil || factory == nil {
@@ -69,11 +110,36 @@ func buildSystemWithOption(nodId, nodeIp string, nodePort int, loader core.IActo
 
 	sys.ps = pubsub.BuildWithOption()
 
-	sys.addressbook = addressbook.New(core.AddressInfo{
+	sys.wheel = timewheel.New(timewheel.DefaultTick, timewheel.DefaultSlotBits)
+	sys.wheel.Start()
+
+	nodeaddr := core.AddressInfo{
 		Node: sys.nodeID,
 		Ip:   sys.nodeIP,
 		Port: sys.nodePort,
-	})
+	}
+	if p.AddressBook != nil {
+		sys.addressbook = p.AddressBook(nodeaddr)
+	} else {
+		sys.addressbook = addressbook.New(nodeaddr)
+	}
+	if p.AddressBookCache != nil {
+		sys.addressbook = addressbook.NewCache(sys.addressbook, addressbook.DefaultCacheTransport(sys.addressbook),
+			p.AddressBookCache.Size, p.AddressBookCache.TTL)
+	}
+
+	sys.redis = !addressbook.InMemory(sys.addressbook)
+	sys.reminderStop = make(chan struct{})
+	if sys.redis {
+		go sys.runReminders()
+	}
+
+	sys.lease, sys.nodeDown = p.Lease, p.NodeDown
+	if sys.lease <= 0 {
+		sys.lease = defaultLease
+	}
+	sys.leaseStop = make(chan struct{})
+	sys.startLease()
 
 	if sys.nodePort != 0 {
 		sys.acceptor, err = NewAcceptor(sys, sys.nodePort, trac)
@@ -96,6 +162,10 @@ func (sys *NormalSystem) AddressBook() core.IAddressBook {
 	return sys.addressbook
 }
 
+func (sys *NormalSystem) TimingWheel() *timewheel.TimingWheel {
+	return sys.wheel
+}
+
 func (sys *NormalSystem) Register(ctx context.Context, builder core.IActorBuilder) (core.IActor, error) {
 
 	if builder.GetID() == "" || builder.GetType() == "" {
@@ -109,7 +179,7 @@ func (sys *NormalSystem) Register(ctx context.Context, builder core.IActorBuilde
 	}
 	sys.Unlock()
 
-	if builder.GetGlobalQuantityLimit() != 0 {
+	if builder.GetGlobalQuantityLimit() != 0 && !builder.GetLocal() {
 
 		if builder.GetNodeUnique() {
 			for _, v := range sys.actoridmap {
@@ -128,17 +198,24 @@ func (sys *NormalSystem) Register(ctx context.Context, builder core.IActorBuilde
 		}
 	}
 
-	// Register first, then build
-	err := sys.addressbook.Register(ctx, builder.GetType(), builder.GetID(), builder.GetWeight())
-	if err != nil {
+	if err := sys.adopt(builder); err != nil {
 		return nil, err
 	}
 
+	// Register first, then build, local actors are not visible to the other nodes
+	if !builder.GetLocal() {
+		err := sys.addressbook.Register(ctx, builder.GetType(), builder.GetID(), builder.GetWeight())
+		if err != nil {
+			sys.orphan(builder.GetID())
+			return nil, err
+		}
+	}
+
 	// Instantiate actor
 	var actor core.IActor
 	if builder.GetConstructor() != nil {
 		actor = builder.GetConstructor()(builder)
-		actor.Init(ctx)
+		actor.Init(context.WithValue(ctx, core.ActorBuilderKey{}, builder))
 	} else {
 		panic(fmt.Errorf("braid.system actor %v register err, constructor is nil", builder.GetType()))
 	}
@@ -147,13 +224,45 @@ func (sys *NormalSystem) Register(ctx context.Context, builder core.IActorBuilde
 	sys.actoridmap[builder.GetID()] = actor
 	sys.Unlock()
 
-	log.InfoF("braid.system node %v register %v %v succ", sys.addressbook.NodeID, builder.GetType(), builder.GetID())
+	startCtx, cancel := context.WithTimeout(ctx, sys.callTimeout)
+	defer cancel()
+	if err := actor.Start(startCtx); err != nil {
+		sys.rollbackRegister(context.WithoutCancel(ctx), builder, actor)
+		return nil, fmt.Errorf("braid.system register actor %v start err %w", builder.GetID(), err)
+	}
+
+	log.InfoF("braid.system node %v register %v %v succ", sys.nodeID, builder.GetType(), builder.GetID())
 	return actor, nil
 }
 
+// rollbackRegister removes an actor which failed to start, it did not handle any message
+func (sys *NormalSystem) rollbackRegister(ctx context.Context, builder core.IActorBuilder, actor core.IActor) {
+	sys.Lock()
+	delete(sys.actoridmap, builder.GetID())
+	sys.Unlock()
+
+	// a start hook which timed out is still running, exit does not wait for it
+	go actor.Exit()
+
+	if sys.orphan(builder.GetID()) {
+		return
+	}
+
+	if err := sys.addressbook.Unregister(ctx, builder.GetID(), builder.GetWeight()); err != nil {
+		log.WarnF("braid.system rollback register actor %v err %v", builder.GetID(), err)
+	}
+}
+
 func (sys *NormalSystem) Unregister(id, ty string) error {
 	// First, check if the actor exists and get it
-	log.InfoF("braid.system unregister actor id %v node %v ty %v", id, sys.addressbook.NodeID, ty)
+	log.InfoF("braid.system unregister actor id %v node %v ty %v", id, sys.nodeID, ty)
+
+	// children stop before their parent
+	for _, child := range sys.Children(id) {
+		if err := sys.Unregister(child.ID(), child.Type()); err != nil {
+			log.WarnF("braid.system unregister child %v of %v err %v", child.ID(), id, err)
+		}
+	}
 
 	sys.RLock()
 	actor, exists := sys.actoridmap[id]
@@ -175,12 +284,16 @@ func (sys *NormalSystem) Unregister(id, ty string) error {
 		return fmt.Errorf("braid.system unregister actor id %v unknown type %v", id, ty)
 	}
 
-	err := sys.addressbook.Unregister(context.TODO(), id, sys.factory.Get(ty).Weight)
-	if err != nil {
-		// Log the error, but don't return it as the actor has already been removed locally
-		log.WarnF("braid.system unregister actor id %s failed from address book err: %v", id, err)
+	if !sys.orphan(id) {
+		err := sys.addressbook.Unregister(context.TODO(), id, sys.factory.Get(ty).Weight)
+		if err != nil {
+			// Log the error, but don't return it as the actor has already been removed locally
+			log.WarnF("braid.system unregister actor id %s failed from address book err: %v", id, err)
+		}
 	}
 
+	sys.notifyTerminated(context.TODO(), core.TerminatedUnregistered, id)
+
 	log.InfoF("braid.system unregister actor id %s successfully", id)
 
 	return nil
@@ -194,7 +307,18 @@ func (sys *NormalSystem) Actors() []core.IActor {
 	return actors
 }
 
-func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error {
+func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error {
+	p := core.BuildCallParm(opts...)
+
+	return sys.attempt(mw, p, true, func(amw *msg.Wrapper) error {
+		if sys.waits != nil && len(amw.Req.Header.CallPath) > 0 {
+			return sys.traceCall(idOrSymbol, actorType, event, amw, p)
+		}
+		return sys.call(idOrSymbol, actorType, event, amw, p)
+	})
+}
+
+func (sys *NormalSystem) call(idOrSymbol, actorType, event string, mw *msg.Wrapper, p core.CallParm) error {
 	// Set message header information
 	mw.Req.Header.Event = event
 	mw.Req.Header.TargetActorID = idOrSymbol
@@ -204,7 +328,7 @@ func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapp
 	var actor core.IActor
 	var err error
 
-	if sys.trac != nil {
+	if sys.trac != nil && !p.NoTrace {
 		span, err := sys.trac.GetSpan(span.SystemCall)
 		if err == nil {
 			mw.Ctx = span.Begin(mw.Ctx)
@@ -223,15 +347,19 @@ func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapp
 		return fmt.Errorf("braid.system call unknown target id")
 	}
 
+	if isMulticast(idOrSymbol) {
+		return fmt.Errorf("braid.system call symbol %v can only be used with send", idOrSymbol)
+	}
+
 	switch idOrSymbol {
 	case def.SymbolWildcard:
-		info, err = sys.addressbook.GetWildcardActor(mw.Ctx, actorType)
+		info, err = sys.addressbook.GetWildcardActor(mw.Ctx, actorType, sys.selector(actorType))
 		// Check if the wildcard actor is local
 		sys.RLock()
 		actor, ok := sys.actoridmap[info.ActorId]
 		sys.RUnlock()
 		if ok {
-			return sys.localCall(actor, mw)
+			return sys.localCall(actor, mw, p.Timeout)
 		}
 	case def.SymbolLocalFirst:
 		actor, info, err = sys.findLocalOrWildcardActor(mw.Ctx, actorType)
@@ -240,7 +368,20 @@ func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapp
 		}
 		if actor != nil {
 			// Local call
-			return sys.localCall(actor, mw)
+			return sys.localCall(actor, mw, p.Timeout)
+		}
+	case def.SymbolHash:
+		info, err = sys.hashActor(mw.Ctx, actorType, mw.Req.Header.RoutingKey)
+		if err == nil {
+			// the node of the actor delivers it without routing the key again
+			mw.Req.Header.TargetActorID = info.ActorId
+
+			sys.RLock()
+			actor, ok := sys.actoridmap[info.ActorId]
+			sys.RUnlock()
+			if ok {
+				return sys.localCall(actor, mw, p.Timeout)
+			}
 		}
 	default:
 		// First, check if it's a local call
@@ -249,12 +390,19 @@ func (sys *NormalSystem) Call(idOrSymbol, actorType, event string, mw *msg.Wrapp
 		sys.RUnlock()
 
 		if ok {
-			return sys.localCall(actorp, mw)
+			return sys.localCall(actorp, mw, p.Timeout)
 		}
 
 		// If not local, get from addressbook
 		info, err = sys.addressbook.GetByID(mw.Ctx, idOrSymbol)
 		log.InfoF("braid.system id call %v is not local, get from addressbook ip %v port %v err %v", idOrSymbol, info.Ip, info.Port, err)
+
+		if errors.Is(err, core.ErrUnknownActor) && sys.activatable(actorType) {
+			actorp, info, err = sys.activate(mw.Ctx, idOrSymbol, actorType)
+			if err == nil && actorp != nil {
+				return sys.localCall(actorp, mw, p.Timeout)
+			}
+		}
 	}
 
 	if err != nil {
@@ -286,61 +434,47 @@ func (sys *NormalSystem) findLocalOrWildcardActor(ctx context.Context, ty string
 	sys.RUnlock()
 
 	// If not found locally, use GetWildcardActor to perform a random search across the cluster
-	info, err := sys.addressbook.GetWildcardActor(ctx, ty)
+	info, err := sys.addressbook.GetWildcardActor(ctx, ty, sys.selector(ty))
 	return nil, info, err
 }
 
-func (sys *NormalSystem) localCall(actorp core.IActor, mw *msg.Wrapper) error {
-
-	root := mw.GetWg().Count() == 0
-	if root {
-		log.InfoF("braid.system local call root event %v id %v", mw.Req.Header.Event, mw.Req.Header.TargetActorID)
-		mw.Done = make(chan struct{})
-		ready := make(chan struct{})
-		go func() {
-			<-ready // Wait for Received to complete
-
-			waitCh := make(chan struct{})
-			go func() {
-				mw.GetWg().Wait()
-				close(waitCh)
-			}()
-
-			select {
-			case <-waitCh:
-				// 正常完成
-			case <-time.After(sys.callTimeout):
-				log.WarnF("braid.system wait timeout for event %v id %v, remaining tasks: %d",
-					mw.Req.Header.Event, mw.Req.Header.TargetActorID, mw.GetWg().Count())
-				if mw.Err == nil {
-					mw.Err = fmt.Errorf("braid.system wait timeout, some tasks did not complete")
-				}
-			}
+// localCall delivers a call to a local actor and waits for its reply (see msg.Wrapper.Reply),
+// or until the timeout (the timeout of the system if 0) expired
+func (sys *NormalSystem) localCall(actorp core.IActor, mw *msg.Wrapper, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = sys.callTimeout
+	}
 
-			close(mw.Done)
-		}()
+	log.InfoF("braid.system local call event %v id %v", mw.Req.Header.Event, mw.Req.Header.TargetActorID)
+	if !mw.ExpectReply() {
+		// the wrapper is in flight in the call of its caller, this call waits on a copy of its own
+		org := mw
+		mw = msg.Clone(org)
+		mw.Res = org.Res
+		mw.ExpectReply()
+		defer func() { org.Err = mw.Err }()
+	}
+	if err := actorp.Received(mw); err != nil {
+		return err
+	}
 
-		if err := actorp.Received(mw); err != nil {
-			close(ready) // Ensure the ready channel is closed even in case of an error
-			return err
+	select {
+	case <-mw.Replied():
+		return nil
+	case <-time.After(timeout):
+		log.WarnF("braid.system wait timeout for event %v id %v", mw.Req.Header.Event, mw.Req.Header.TargetActorID)
+		if mw.Err == nil {
+			mw.Err = fmt.Errorf("braid.system wait timeout, no reply for the call")
 		}
-		close(ready) // Notify the goroutine that Received has completed
-
-		select {
-		case <-mw.Done:
-			return nil
-		case <-mw.Ctx.Done():
-			timeoutErr := fmt.Errorf("braid actor %v message %v processing timed out",
-				mw.Req.Header.TargetActorID, mw.Req.Header.Event)
-			if mw.Err != nil {
-				timeoutErr = fmt.Errorf("%w: %v", mw.Err, timeoutErr)
-			}
-			mw.Err = timeoutErr
-			return timeoutErr
+		return nil
+	case <-mw.Ctx.Done():
+		timeoutErr := fmt.Errorf("braid actor %v message %v processing timed out",
+			mw.Req.Header.TargetActorID, mw.Req.Header.Event)
+		if mw.Err != nil {
+			timeoutErr = fmt.Errorf("%w: %v", mw.Err, timeoutErr)
 		}
-	} else {
-		log.InfoF("braid.system local call received event %v id %v", mw.Req.Header.Event, mw.Req.Header.TargetActorID)
-		return actorp.Received(mw)
+		mw.Err = timeoutErr
+		return timeoutErr
 	}
 }
 
@@ -353,6 +487,8 @@ func (sys *NormalSystem) handleRemoteCall(ctx context.Context, addrinfo core.Add
 		res)
 
 	if err != nil {
+		sys.invalidateAddress(addrinfo.ActorId)
+		sys.DeadLetter(mw, core.DeadLetterRemoteFailure, err)
 		return err
 	}
 
@@ -360,7 +496,36 @@ func (sys *NormalSystem) handleRemoteCall(ctx context.Context, addrinfo core.Add
 	return nil
 }
 
-func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapper) error {
+// invalidateAddress drops the cached address of an actor which could not be reached, the next lookup fetches it again
+func (sys *NormalSystem) invalidateAddress(id string) {
+	if c, ok := sys.addressbook.(core.IAddressBookCache); ok {
+		c.Invalidate(id)
+	}
+}
+
+func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...core.CallOption) error {
+	p := core.BuildCallParm(opts...)
+
+	return sys.attempt(mw, p, false, func(amw *msg.Wrapper) error {
+		return sys.send(idOrSymbol, actorType, event, sendWrapper(amw), p)
+	})
+}
+
+// sendWrapper returns the wrapper delivered by a send, a sent message blocks no one and carries no call path
+//
+//	mw may be the message of the running handler (a forward), its path is still used by the calls of the
+//	handler, the path is dropped from a copy
+func sendWrapper(mw *msg.Wrapper) *msg.Wrapper {
+	if len(mw.Req.Header.CallPath) == 0 {
+		return mw
+	}
+
+	smw := msg.Clone(mw)
+	smw.Req.Header.CallPath = nil
+	return smw
+}
+
+func (sys *NormalSystem) send(idOrSymbol, actorType, event string, mw *msg.Wrapper, p core.CallParm) error {
 	// Set message header information
 	mw.Req.Header.Event = event
 	mw.Req.Header.TargetActorID = idOrSymbol
@@ -370,7 +535,7 @@ func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapp
 	var actor core.IActor
 	var err error
 
-	if sys.trac != nil {
+	if sys.trac != nil && !p.NoTrace {
 		span, err := sys.trac.GetSpan(span.SystemCall)
 		if err == nil {
 			mw.Ctx = span.Begin(mw.Ctx)
@@ -389,9 +554,13 @@ func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapp
 		return fmt.Errorf("braid.system send unknown target id")
 	}
 
+	if isMulticast(idOrSymbol) {
+		return sys.multicast(idOrSymbol, actorType, mw)
+	}
+
 	switch idOrSymbol {
 	case def.SymbolWildcard:
-		info, err = sys.addressbook.GetWildcardActor(mw.Ctx, actorType)
+		info, err = sys.addressbook.GetWildcardActor(mw.Ctx, actorType, sys.selector(actorType))
 		// Check if the wildcard actor is local
 		sys.RLock()
 		actor, ok := sys.actoridmap[info.ActorId]
@@ -407,6 +576,19 @@ func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapp
 		if actor != nil {
 			return actor.Received(mw)
 		}
+	case def.SymbolHash:
+		info, err = sys.hashActor(mw.Ctx, actorType, mw.Req.Header.RoutingKey)
+		if err == nil {
+			// the node of the actor delivers it without routing the key again
+			mw.Req.Header.TargetActorID = info.ActorId
+
+			sys.RLock()
+			actor, ok := sys.actoridmap[info.ActorId]
+			sys.RUnlock()
+			if ok {
+				return actor.Received(mw)
+			}
+		}
 	default:
 		// First, check if it's a local call
 		sys.RLock()
@@ -419,6 +601,12 @@ func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapp
 
 		// If not local, get from addressbook
 		info, err = sys.addressbook.GetByID(mw.Ctx, idOrSymbol)
+
+		if errors.Is(err, core.ErrUnknownActor) && sys.activatable(actorType) {
+			// the message is held until the actor exists, the sender does not wait for the activation
+			go sys.activateSend(idOrSymbol, actorType, mw)
+			return nil
+		}
 	}
 
 	if err != nil {
@@ -437,11 +625,21 @@ func (sys *NormalSystem) Send(idOrSymbol, actorType, event string, mw *msg.Wrapp
 }
 
 func (sys *NormalSystem) handleRemoteSend(info core.AddressInfo, mw *msg.Wrapper) error {
-	return sys.client.Call(mw.Ctx,
-		fmt.Sprintf("%s:%d", info.Ip, info.Port),
-		"/router.Acceptor/routing",
-		&router.RouteReq{Msg: mw.Req},
-		nil) // We don't need the response for Send
+	addr := fmt.Sprintf("%s:%d", info.Ip, info.Port)
+	err := sys.client.Connect(addr)
+	if err == nil {
+		err = sys.client.Call(mw.Ctx,
+			addr,
+			"/router.Acceptor/routing",
+			&router.RouteReq{Msg: mw.Req},
+			&router.RouteRes{}) // We don't need the response for Send
+	}
+
+	if err != nil {
+		sys.invalidateAddress(info.ActorId)
+		sys.DeadLetter(mw, core.DeadLetterRemoteFailure, err)
+	}
+	return err
 }
 
 func (sys *NormalSystem) Pub(topic string, event string, body []byte) error {
@@ -464,6 +662,28 @@ func (sys *NormalSystem) FindActor(ctx context.Context, id string) (core.IActor,
 	return nil, fmt.Errorf("braid.system find actor %v err", id)
 }
 
+func (sys *NormalSystem) Replace(id string, actor core.IActor) error {
+	sys.Lock()
+	defer sys.Unlock()
+
+	if _, ok := sys.actoridmap[id]; !ok {
+		return fmt.Errorf("braid.system replace actor %v err, actor not registered", id)
+	}
+
+	sys.actoridmap[id] = actor
+	return nil
+}
+
+// HandleFailure is the system supervisor, it receives the failures escalated by actors without a parent
+func (sys *NormalSystem) HandleFailure(f core.Failure) core.SupervisorDirective {
+	if sys.supervisor != nil {
+		return sys.supervisor.HandleFailure(f)
+	}
+
+	log.WarnF("braid.system actor %v ty %v escalated failure %v, stopping", f.ActorID, f.ActorTy, f.Reason)
+	return core.SupervisorStop
+}
+
 func (sys *NormalSystem) Exit(wait *sync.WaitGroup) {
 	if sys.nodePort != 0 {
 		wait.Add(1)
@@ -474,7 +694,9 @@ func (sys *NormalSystem) Exit(wait *sync.WaitGroup) {
 		wait.Done()
 	}
 
+	ids := make([]string, 0, len(sys.actoridmap))
 	for _, actor := range sys.actoridmap {
+		ids = append(ids, actor.ID())
 		wait.Add(1)
 
 		go func(a core.IActor) {
@@ -484,9 +706,25 @@ func (sys *NormalSystem) Exit(wait *sync.WaitGroup) {
 		}(actor)
 	}
 
+	// the lease is not refreshed once the records of the node are cleared, an in-flight heartbeat
+	// would restore them
+	close(sys.leaseStop)
+	sys.leaseDone.Wait()
+
 	err := sys.addressbook.Clear(context.TODO())
 	if err != nil {
 		log.WarnF("[braid.addressbook] clear err %v", err.Error())
 	}
 	log.InfoF("braid.system addressbook exit")
+
+	sys.notifyTerminated(context.TODO(), core.TerminatedExit, ids...)
+
+	sys.wheel.Stop()
+	close(sys.reminderStop)
+
+	for _, sink := range sys.deadLetters {
+		if c, ok := sink.(core.IDeadLetterSinkCloser); ok {
+			c.Close()
+		}
+	}
 }
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/lib/log"
+)
+
+// defaultLease is the ttl of the lease of a node in the address book
+const defaultLease = 10 * time.Second
+
+// startLease takes the lease of the node and runs its heartbeat, the reaper of the dead nodes
+// and the poll of the node down log until the system exits
+func (sys *NormalSystem) startLease() {
+	if err := sys.addressbook.KeepAlive(context.TODO(), sys.lease); err != nil {
+		log.WarnF("braid.system node %v take lease err %v", sys.nodeID, err)
+	}
+
+	// the downs logged before the node started are not reported
+	_, cursor, err := sys.addressbook.NodeDowns(context.TODO(), -1)
+	if err != nil {
+		log.WarnF("braid.system node %v get node down cursor err %v", sys.nodeID, err)
+	}
+
+	sys.leaseDone.Add(2)
+	go sys.runHeartbeat(cursor)
+	go sys.runReaper()
+}
+
+// runHeartbeat refreshes the lease every lease/3 and reports the node downs
+func (sys *NormalSystem) runHeartbeat(cursor int64) {
+	defer sys.leaseDone.Done()
+
+	ticker := time.NewTicker(sys.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := sys.addressbook.KeepAlive(context.TODO(), sys.lease)
+			if errors.Is(err, core.ErrLeaseLost) {
+				// the node was reaped while alive, the address book registered its actors and groups again
+				log.WarnF("braid.system node %v lost its lease, records restored", sys.nodeID)
+			} else if err != nil {
+				log.WarnF("braid.system node %v refresh lease err %v", sys.nodeID, err)
+			}
+
+			downs, next, err := sys.addressbook.NodeDowns(context.TODO(), cursor)
+			if err != nil {
+				log.WarnF("braid.system node %v get node downs err %v", sys.nodeID, err)
+				continue
+			}
+			cursor = next
+
+			for _, down := range downs {
+				log.InfoF("braid.system node %v is down, %v actors reaped", down.Node, len(down.Actors))
+				if sys.nodeDown != nil {
+					sys.nodeDown(down)
+				}
+			}
+		case <-sys.leaseStop:
+			return
+		}
+	}
+}
+
+// runReaper reaps the nodes whose lease expired, the watchers of their actors receive a TerminatedNodeDown
+//
+//	it runs apart from the heartbeat, reaping a node waits on its distributed mutex
+func (sys *NormalSystem) runReaper() {
+	defer sys.leaseDone.Done()
+
+	ticker := time.NewTicker(sys.lease / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			downs, err := sys.addressbook.Reap(context.TODO())
+			if err != nil {
+				log.WarnF("braid.system node %v reap err %v", sys.nodeID, err)
+			}
+
+			for _, down := range downs {
+				ids := make([]string, 0, len(down.Actors))
+				for _, actor := range down.Actors {
+					ids = append(ids, actor.ActorId)
+				}
+				sys.notifyTerminated(context.TODO(), core.TerminatedNodeDown, ids...)
+			}
+		case <-sys.leaseStop:
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+)
+
+// isMulticast checks whether the target is a send-only multicast symbol ("*" or "#group")
+func isMulticast(idOrSymbol string) bool {
+	return idOrSymbol == def.SymbolAll || strings.HasPrefix(idOrSymbol, def.SymbolGroup)
+}
+
+// multicastTargets resolves the addresses of all actors targeted by a multicast symbol
+func (sys *NormalSystem) multicastTargets(ctx context.Context, idOrSymbol, actorType string) ([]core.AddressInfo, error) {
+	if idOrSymbol == def.SymbolAll {
+		return sys.addressbook.GetByType(ctx, actorType)
+	}
+
+	group := strings.TrimPrefix(idOrSymbol, def.SymbolGroup)
+	if group == "" {
+		return nil, fmt.Errorf("braid.system send to group with empty name")
+	}
+
+	members, err := sys.addressbook.GetGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorType == "" {
+		return members, nil
+	}
+
+	targets := members[:0]
+	for _, member := range members {
+		if member.ActorTy == actorType {
+			targets = append(targets, member)
+		}
+	}
+	return targets, nil
+}
+
+// multicast delivers the message to every actor targeted by "*" or "#group"
+//
+//	local actors receive a copy of the message directly, remote targets are grouped by node
+//	so that each node receives a single RPC and fans the message out to its own actors.
+//	The RPC carries the ids of the targets of the node (Header.Targets), the nodes do not resolve the group again
+func (sys *NormalSystem) multicast(idOrSymbol, actorType string, mw *msg.Wrapper) error {
+	if idOrSymbol == def.SymbolAll && actorType == "" {
+		return fmt.Errorf("braid.system send %v without actor type", idOrSymbol)
+	}
+
+	targets, err := sys.multicastTargets(mw.Ctx, idOrSymbol, actorType)
+	if err != nil {
+		return fmt.Errorf("braid.system send %v ty %v err %w", idOrSymbol, actorType, err)
+	}
+
+	nodes := make(map[string]core.AddressInfo)
+	nodeTargets := make(map[string][]string)
+	var local []string
+
+	for _, info := range targets {
+		if info.Node == sys.nodeID {
+			local = append(local, info.ActorId)
+			continue
+		}
+		if _, ok := nodes[info.Node]; !ok {
+			nodes[info.Node] = info
+		}
+		nodeTargets[info.Node] = append(nodeTargets[info.Node], info.ActorId)
+	}
+
+	var errs []string
+
+	if len(local) > 0 {
+		if err := sys.localMulticast(idOrSymbol, actorType, mw, local); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for node, info := range nodes {
+		nmw := msg.Clone(mw)
+		nmw.Req.Header.Targets = nodeTargets[node]
+
+		if err := sys.handleRemoteSend(info, nmw); err != nil {
+			errs = append(errs, fmt.Sprintf("node %v: %v", info.Node, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("braid.system send %v ty %v partially failed: %v", idOrSymbol, actorType, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// localMulticast delivers a copy of the message to the actors of the current node targeted by "*" or "#group"
+//
+//	ids are the targets resolved by the sender, nil resolves them here (a message of a sender which ships no targets)
+func (sys *NormalSystem) localMulticast(idOrSymbol, actorType string, mw *msg.Wrapper, ids []string) error {
+	var actors []core.IActor
+
+	if ids == nil {
+		if idOrSymbol == def.SymbolAll {
+			sys.RLock()
+			for id, actor := range sys.actoridmap {
+				if actor.Type() == actorType {
+					ids = append(ids, id)
+				}
+			}
+			sys.RUnlock()
+		} else {
+			targets, err := sys.multicastTargets(mw.Ctx, idOrSymbol, actorType)
+			if err != nil {
+				return err
+			}
+			for _, info := range targets {
+				if info.Node == sys.nodeID {
+					ids = append(ids, info.ActorId)
+				}
+			}
+		}
+	}
+
+	sys.RLock()
+	for _, id := range ids {
+		if actor, ok := sys.actoridmap[id]; ok {
+			actors = append(actors, actor)
+		}
+	}
+	sys.RUnlock()
+
+	for _, actor := range actors {
+		cmw := msg.Clone(mw)
+		cmw.Req.Header.TargetActorID = actor.ID()
+		cmw.Req.Header.Targets = nil
+
+		if err := actor.Received(cmw); err != nil {
+			log.WarnF("braid.system send %v to actor %v err %v", idOrSymbol, actor.ID(), err)
+		}
+	}
+
+	return nil
+}
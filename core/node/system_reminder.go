@@ -0,0 +1,260 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// reminderPoll is how often a node claims the due reminders
+	reminderPoll = 200 * time.Millisecond
+
+	// reminderLease is how long a claimed fire stays invisible to the other nodes,
+	// a fire which was not acknowledged within the lease is delivered again
+	reminderLease = 10 * time.Second
+
+	reminderBatch = 128
+)
+
+// claim the due reminders, their score is pushed back by the lease until the fire is acknowledged
+var reminderClaimScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, member in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[2], member)
+end
+return due`)
+
+// acknowledge a delivered fire, unless the reminder was replaced or cancelled in the meantime
+//
+//	ARGV: key, claimed json, next json ("" to remove the reminder), next score, name
+var reminderAckScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('SREM', KEYS[3], ARGV[5])
+else
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+	redis.call('ZADD', KEYS[1], ARGV[4], ARGV[1])
+end
+return 1`)
+
+func reminderKey(actorID, name string) string {
+	return actorID + ":" + name
+}
+
+func (sys *NormalSystem) RegisterReminder(ctx context.Context, r core.Reminder) error {
+	if r.ActorID == "" || r.ActorTy == "" || r.Name == "" || r.Event == "" {
+		return fmt.Errorf("braid.system register reminder %v of actor %v ty %v parm err", r.Name, r.ActorID, r.ActorTy)
+	}
+	if !sys.redis {
+		return core.ErrRedisRequired
+	}
+
+	byt, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	key := reminderKey(r.ActorID, r.Name)
+	_, err = trdredis.TxPipelined(ctx, "[braid.reminder.register]", func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, def.RedisReminderDataField, key, string(byt))
+		pipe.ZAdd(ctx, def.RedisReminderDueField, redis.Z{Score: float64(r.Due.UnixMilli()), Member: key})
+		pipe.SAdd(ctx, def.RedisReminderActorField+r.ActorID, r.Name)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("braid.system register reminder %v err %w", key, err)
+	}
+
+	return nil
+}
+
+func (sys *NormalSystem) Reminders(ctx context.Context, actorID string) ([]core.Reminder, error) {
+	if !sys.redis {
+		return nil, core.ErrRedisRequired
+	}
+
+	names, err := trdredis.SMembers(ctx, def.RedisReminderActorField+actorID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("braid.system list reminders of %v err %w", actorID, err)
+	}
+
+	reminders := make([]core.Reminder, 0, len(names))
+	for _, name := range names {
+		data, err := trdredis.HGet(ctx, def.RedisReminderDataField, reminderKey(actorID, name)).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("braid.system list reminders of %v err %w", actorID, err)
+		}
+
+		var r core.Reminder
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+
+	return reminders, nil
+}
+
+func (sys *NormalSystem) CancelReminder(ctx context.Context, actorID, name string) error {
+	if !sys.redis {
+		return core.ErrRedisRequired
+	}
+
+	key := reminderKey(actorID, name)
+	_, err := trdredis.TxPipelined(ctx, "[braid.reminder.cancel]", func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, def.RedisReminderDueField, key)
+		pipe.HDel(ctx, def.RedisReminderDataField, key)
+		pipe.SRem(ctx, def.RedisReminderActorField+actorID, name)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("braid.system cancel reminder %v err %w", key, err)
+	}
+
+	return nil
+}
+
+// runReminders claims and delivers the due reminders until the system exits
+func (sys *NormalSystem) runReminders() {
+	ticker := time.NewTicker(reminderPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sys.fireReminders(context.TODO())
+		case <-sys.reminderStop:
+			return
+		}
+	}
+}
+
+func (sys *NormalSystem) fireReminders(ctx context.Context) {
+	now := time.Now()
+
+	keys, err := trdredis.ScriptRun(ctx, reminderClaimScript, []string{def.RedisReminderDueField},
+		now.UnixMilli(), now.Add(reminderLease).UnixMilli(), reminderBatch)
+	if err != nil {
+		if err != redis.Nil {
+			log.WarnF("braid.system claim reminders err %v", err)
+		}
+		return
+	}
+
+	claimed, _ := keys.([]interface{})
+	for _, key := range claimed {
+		if k, ok := key.(string); ok {
+			go sys.fireReminder(ctx, k, now)
+		}
+	}
+}
+
+// fireReminder delivers a claimed fire, then acknowledges it by scheduling the next fire or removing the reminder
+//
+//	a failed delivery is not acknowledged, the fire is claimed again once its lease expires
+func (sys *NormalSystem) fireReminder(ctx context.Context, key string, now time.Time) {
+	data, err := trdredis.HGet(ctx, def.RedisReminderDataField, key).Result()
+	if err != nil {
+		if err == redis.Nil { // cancelled
+			trdredis.ZRem(ctx, def.RedisReminderDueField, key)
+		}
+		return
+	}
+
+	var r core.Reminder
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		log.WarnF("braid.system reminder %v unmarshal err %v", key, err)
+		return
+	}
+
+	fire := key + ":" + fmt.Sprint(r.Due.UnixMilli())
+	if !reminderFired(ctx, fire) {
+		mw := msg.NewBuilder(context.TODO()).
+			WithReqBody(r.Body).
+			WithReqCustomFields(def.Reminder(r.Name)).
+			Build()
+		mw.Req.Header.ID = def.ReminderIDPrefix + fire
+
+		if err := sys.deliverReminder(ctx, r, mw); err != nil {
+			log.WarnF("braid.system reminder %v deliver err %v, retry in %v", key, err, reminderLease)
+			return
+		}
+		markReminderFire(ctx, fire, r.Period)
+	} else {
+		// delivered by a claim whose acknowledgement was lost, only the acknowledgement is retried
+		log.InfoF("braid.system reminder %v drop duplicated fire %v", key, fire)
+	}
+
+	next, score := "", int64(0)
+	if r.Period > 0 {
+		// missed fires are collapsed into the one just delivered
+		r.Due = r.Due.Add((now.Sub(r.Due)/r.Period + 1) * r.Period)
+		byt, err := json.Marshal(r)
+		if err != nil {
+			return
+		}
+		next, score = string(byt), r.Due.UnixMilli()
+	}
+
+	_, err = trdredis.ScriptRun(ctx, reminderAckScript,
+		[]string{def.RedisReminderDueField, def.RedisReminderDataField, def.RedisReminderActorField + r.ActorID},
+		key, data, next, score, r.Name)
+	if err != nil {
+		log.WarnF("braid.system reminder %v ack err %v", key, err)
+	}
+}
+
+// reminderFired returns true if the fire was already delivered
+func reminderFired(ctx context.Context, fire string) bool {
+	n, err := trdredis.Exists(ctx, def.RedisReminderFiredField+fire).Result()
+	if err != nil {
+		// prefer a duplicate over a lost fire
+		log.WarnF("braid.system reminder fire %v dedup err %v", fire, err)
+		return false
+	}
+	return n > 0
+}
+
+// markReminderFire marks a fire as delivered, once it was handed to the actor
+//
+//	an owner which dies before the mark leaves the fire to the next claim (at least once), a fire is claimed
+//	again once its lease expired without an acknowledgement, the mark lives for the lease and one period of
+//	the reminder, the window in which the fire can be claimed again
+func markReminderFire(ctx context.Context, fire string, period time.Duration) {
+	ttl := reminderLease + period
+	if period <= 0 {
+		ttl = reminderLease * 2
+	}
+
+	if err := trdredis.SetEx(ctx, def.RedisReminderFiredField+fire, 1, ttl).Err(); err != nil {
+		log.WarnF("braid.system reminder fire %v mark err %v", fire, err)
+	}
+}
+
+// deliverReminder waits for the activation of an unknown target, so that a fire is only acknowledged once it was handed to the actor
+func (sys *NormalSystem) deliverReminder(ctx context.Context, r core.Reminder, mw *msg.Wrapper) error {
+	_, err := sys.addressbook.GetByID(ctx, r.ActorID)
+	if errors.Is(err, core.ErrUnknownActor) && sys.activatable(r.ActorTy) {
+		if _, _, err := sys.activate(ctx, r.ActorID, r.ActorTy); err != nil {
+			return err
+		}
+	}
+
+	return sys.Send(r.ActorID, r.ActorTy, r.Event, mw)
+}
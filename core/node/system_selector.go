@@ -0,0 +1,28 @@
+package node
+
+import (
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/addressbook"
+)
+
+// selector returns the selector of the wildcard calls to the actor type, named by core.SelectorOption in the options
+// of its constructor. The selectors are kept per type, as the round robin selector holds its turn
+func (sys *NormalSystem) selector(actorType string) core.ISelector {
+	sys.selectorMu.Lock()
+	defer sys.selectorMu.Unlock()
+
+	if s, ok := sys.selectors[actorType]; ok {
+		return s
+	}
+
+	name := ""
+	if sys.factory != nil {
+		if c := sys.factory.Get(actorType); c != nil {
+			name = c.Options[core.SelectorOption]
+		}
+	}
+
+	s := addressbook.NewSelector(name)
+	sys.selectors[actorType] = s
+	return s
+}
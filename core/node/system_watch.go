@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/log"
+	"github.com/pojol/braid/router/msg"
+	"github.com/redis/go-redis/v9"
+)
+
+func (sys *NormalSystem) Watch(ctx context.Context, watcherID, watcherTy, targetID string) error {
+	if watcherID == "" || watcherTy == "" || targetID == "" {
+		return fmt.Errorf("braid.system watch %v by %v ty %v parm err", targetID, watcherID, watcherTy)
+	}
+	if !sys.redis {
+		return core.ErrRedisRequired
+	}
+
+	key := def.RedisWatchField + targetID
+	if err := trdredis.HSet(ctx, key, watcherID, watcherTy).Err(); err != nil {
+		return fmt.Errorf("braid.system watch %v by %v err %w", targetID, watcherID, err)
+	}
+
+	// the watch is persisted before the target is looked up, so a termination can not slip in between
+	_, err := sys.addressbook.GetByID(ctx, targetID)
+	if errors.Is(err, core.ErrUnknownActor) {
+		// the target is already gone, unless it terminated in the meantime and took the watch with it
+		if n, _ := trdredis.HDel(ctx, key, watcherID).Result(); n == 1 {
+			return sys.sendTerminated(watcherID, watcherTy, core.Terminated{ID: targetID, Reason: core.TerminatedUnknown, Node: sys.nodeID})
+		}
+	} else if err != nil {
+		return fmt.Errorf("braid.system watch %v by %v err %w", targetID, watcherID, err)
+	}
+
+	return nil
+}
+
+func (sys *NormalSystem) Unwatch(ctx context.Context, watcherID, targetID string) error {
+	if !sys.redis {
+		return core.ErrRedisRequired
+	}
+
+	if err := trdredis.HDel(ctx, def.RedisWatchField+targetID, watcherID).Err(); err != nil {
+		return fmt.Errorf("braid.system unwatch %v by %v err %w", targetID, watcherID, err)
+	}
+	return nil
+}
+
+// notifyTerminated takes the watchers of the terminated actors and sends them a Terminated
+//
+//	the watchers are removed in the same transaction they are read, so each watch is notified by a single node.
+//	a node without redis has no watches
+func (sys *NormalSystem) notifyTerminated(ctx context.Context, reason core.TerminatedReason, ids ...string) {
+	if len(ids) == 0 || !sys.redis {
+		return
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err := trdredis.TxPipelined(ctx, "[braid.watch.terminated]", func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, def.RedisWatchField+id)
+			pipe.Del(ctx, def.RedisWatchField+id)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WarnF("braid.system take watchers of %v err %v", ids, err)
+		return
+	}
+
+	for i, id := range ids {
+		watchers, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+
+		for watcherID, watcherTy := range watchers {
+			err := sys.sendTerminated(watcherID, watcherTy, core.Terminated{ID: id, Reason: reason, Node: sys.nodeID})
+			if err != nil {
+				log.WarnF("braid.system notify watcher %v of %v terminated err %v", watcherID, id, err)
+			}
+		}
+	}
+}
+
+func (sys *NormalSystem) sendTerminated(watcherID, watcherTy string, t core.Terminated) error {
+	byt, err := t.Encode()
+	if err != nil {
+		return err
+	}
+
+	return sys.Send(watcherID, watcherTy, core.TerminatedEvent, msg.NewBuilder(context.TODO()).WithReqBody(byt).Build())
+}
@@ -0,0 +1,23 @@
+package core
+
+import "time"
+
+// Reminder is a durable timer persisted in redis, keyed by actor id and name
+//
+//	each fire is delivered as a normal event into the actor's mailbox (through Send, so the actor can live on
+//	any node or be activated on demand), at least once and deduplicated by its fire id
+type Reminder struct {
+	ActorID string
+	ActorTy string
+	Name    string
+	Event   string
+
+	// Due is the time of the next fire
+	Due time.Time
+
+	// Period repeats the reminder, 0 for a one-shot reminder
+	Period time.Duration
+
+	// Body is passed as the request body of every fire
+	Body []byte
+}
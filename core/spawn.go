@@ -0,0 +1,25 @@
+package core
+
+// SpawnParm configures a child actor created by ActorContext.Spawn
+type SpawnParm struct {
+	// Local keeps the child out of the address book, it can only be reached by id from its parent's node
+	Local bool
+
+	// Supervisor of the child, if nil the child's failures are escalated to its parent
+	Supervisor *SupervisorStrategy
+}
+
+type SpawnOption func(*SpawnParm)
+
+// SpawnWithLocal skips the address book registration, meant for short-lived helper actors
+func SpawnWithLocal() SpawnOption {
+	return func(p *SpawnParm) {
+		p.Local = true
+	}
+}
+
+func SpawnWithSupervisor(strategy *SupervisorStrategy) SpawnOption {
+	return func(p *SpawnParm) {
+		p.Supervisor = strategy
+	}
+}
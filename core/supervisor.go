@@ -0,0 +1,60 @@
+package core
+
+import "time"
+
+// SupervisorDirective is the decision a supervisor makes about a failed (panicking) actor
+type SupervisorDirective int
+
+const (
+	// SupervisorResume keeps the actor running with its current state, the failed message is discarded
+	SupervisorResume SupervisorDirective = iota
+
+	// SupervisorRestart exits the actor and replaces it with a fresh instance built by its constructor,
+	// messages pending in the mailbox are handed over to the new instance
+	SupervisorRestart
+
+	// SupervisorStop exits the actor and unregisters it from the address book
+	SupervisorStop
+
+	// SupervisorEscalate hands the failure over to the parent supervisor (the system supervisor if the actor has no parent)
+	SupervisorEscalate
+)
+
+// Failure describes a panic recovered on an actor goroutine
+type Failure struct {
+	ActorID string
+	ActorTy string
+	Reason  interface{}
+	Stack   []byte
+}
+
+// SupervisorStrategy configures how the failures of an actor type are handled
+type SupervisorStrategy struct {
+	// Decider selects the directive for a failure, if nil Directive is used
+	Decider   func(Failure) SupervisorDirective
+	Directive SupervisorDirective
+
+	// MaxRestarts is the number of restarts allowed within Window, once exceeded the actor is stopped (0 means unlimited)
+	MaxRestarts int
+	Window      time.Duration
+
+	// Backoff is the delay before the first restart in the window, doubled for each following restart up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Recovery replaces the default recovery function which logs the panic and its stack trace
+	Recovery func(interface{})
+}
+
+// ISupervisor receives the failures escalated by actors and decides what to do with them
+type ISupervisor interface {
+	HandleFailure(Failure) SupervisorDirective
+}
+
+// Decide returns the directive of the strategy for the failure
+func (s *SupervisorStrategy) Decide(f Failure) SupervisorDirective {
+	if s.Decider != nil {
+		return s.Decider(f)
+	}
+	return s.Directive
+}
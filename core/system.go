@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/pojol/braid/lib/pubsub"
+	"github.com/pojol/braid/lib/timewheel"
 	"github.com/pojol/braid/router/msg"
 )
 
@@ -13,6 +14,28 @@ type CreateFunc func(IActorBuilder) IActor
 
 var ErrActorRegisterRepeat = errors.New("[braid.system] register actor repeat")
 
+// ErrUnknownActor is returned when the target actor is not registered in the address book
+var ErrUnknownActor = errors.New("unknown actor")
+
+// ErrActorPassivated is returned by IActor.Received while the actor is being passivated, the message can be retried
+var ErrActorPassivated = errors.New("[braid.actor] actor is passivated, retry later")
+
+// ErrMailboxFull is returned by IActor.Received when the mailbox is full and the overflow policy rejects the message
+var ErrMailboxFull = errors.New("[braid.actor] mailbox is full")
+
+// ErrNothingToStash is returned by Stash when it is not called from a message handler
+var ErrNothingToStash = errors.New("[braid.actor] no message to stash")
+
+// ErrFutureTimeout is the error of a future which did not complete within its timeout
+var ErrFutureTimeout = errors.New("[braid.actor] future timeout")
+
+// ErrFutureCanceled is the error of a cancelled future
+var ErrFutureCanceled = errors.New("[braid.actor] future canceled")
+
+// ErrRedisRequired is returned by the reminders and watches of a node whose address book lives in memory,
+// they are persisted in redis
+var ErrRedisRequired = errors.New("[braid.system] reminders and watches require the redis address book")
+
 type ISystem interface {
 	Register(context.Context, IActorBuilder) (IActor, error)
 	Unregister(id, ty string) error
@@ -21,13 +44,19 @@ type ISystem interface {
 
 	FindActor(ctx context.Context, id string) (IActor, error)
 
+	// Children returns the registered children of a local actor
+	Children(parentID string) []IActor
+
+	// Replace swaps the local instance of a registered actor, used by supervision to restart an actor with fresh state
+	Replace(id string, actor IActor) error
+
 	// Call sends an event to another actor
 	// Synchronous call semantics (actual implementation is asynchronous, each call is in a separate goroutine)
-	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper) error
+	Call(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) error
 
 	// Send sends an event to another actor
 	// Asynchronous call semantics, does not block the current goroutine, used for long-running RPC calls
-	Send(idOrSymbol, actorType, event string, mw *msg.Wrapper) error
+	Send(idOrSymbol, actorType, event string, mw *msg.Wrapper, opts ...CallOption) error
 
 	// Pub semantics for pubsub, used to publish messages to an actor's message cache queue
 	Pub(topic string, event string, body []byte) error
@@ -41,6 +70,34 @@ type ISystem interface {
 
 	AddressBook() IAddressBook
 
+	// RegisterReminder persists a reminder, registering an existing name of the same actor replaces it.
+	// Reminders are persisted in redis, a node with an in-memory address book returns ErrRedisRequired
+	RegisterReminder(ctx context.Context, r Reminder) error
+
+	// Reminders lists the reminders of an actor
+	Reminders(ctx context.Context, actorID string) ([]Reminder, error)
+
+	// CancelReminder removes a reminder of an actor
+	CancelReminder(ctx context.Context, actorID, name string) error
+
+	// Watch subscribes the watcher to the termination of the target, the watcher receives a TerminatedEvent
+	// when the target exits, is unregistered or its node is declared dead. Watches are persisted in redis,
+	// a node with an in-memory address book returns ErrRedisRequired
+	Watch(ctx context.Context, watcherID, watcherTy, targetID string) error
+
+	// Unwatch removes a watch of the watcher
+	Unwatch(ctx context.Context, watcherID, targetID string) error
+
+	// WaitForGraph returns the synchronous calls in flight from the actors of this node,
+	// it is only recorded on a node built with NodeWithCallGraph
+	WaitForGraph() []WaitFor
+
+	// DeadLetter hands a message which could not be delivered or handled to the dead letter sinks of this node
+	DeadLetter(mw *msg.Wrapper, reason DeadLetterReason, err error)
+
+	// TimingWheel returns the timing wheel shared by the timers of every actor on this node
+	TimingWheel() *timewheel.TimingWheel
+
 	Exit(*sync.WaitGroup)
 }
 
@@ -0,0 +1,42 @@
+package core
+
+import "encoding/json"
+
+// TerminatedReason tells why a watched actor terminated
+type TerminatedReason string
+
+const (
+	// TerminatedExit the actor exited with its node
+	TerminatedExit TerminatedReason = "exit"
+
+	// TerminatedUnregistered the actor was unregistered (stopped, passivated or stopped by its supervisor)
+	TerminatedUnregistered TerminatedReason = "unregistered"
+
+	// TerminatedNodeDown the node of the actor was declared dead
+	TerminatedNodeDown TerminatedReason = "node_down"
+
+	// TerminatedUnknown the actor was not registered when it was watched
+	TerminatedUnknown TerminatedReason = "unknown"
+)
+
+// TerminatedEvent is the event delivered to the watchers of a terminated actor, the body is an encoded Terminated
+const TerminatedEvent = "braid.terminated"
+
+// Terminated notifies a watcher that a watched actor terminated
+//
+//	a watch is removed once its Terminated has been sent, watch the actor again to follow its next incarnation
+type Terminated struct {
+	ID     string
+	Reason TerminatedReason
+	Node   string // node which declared the termination
+}
+
+func (t Terminated) Encode() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func DecodeTerminated(byt []byte) (Terminated, error) {
+	var t Terminated
+	err := json.Unmarshal(byt, &t)
+	return t, err
+}
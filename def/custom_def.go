@@ -6,8 +6,10 @@ const (
 	KeyActorID       = "ActorID"
 	KeyActorTy       = "ActorTy"
 	KeyTranscationID = "TransactionID"
+	KeyReminder      = "Reminder"
 )
 
 func ActorID(id string) msg.Attr       { return msg.Attr{Key: KeyActorID, Value: id} }
 func ActorTy(ty string) msg.Attr       { return msg.Attr{Key: KeyActorTy, Value: ty} }
 func TransactionID(id string) msg.Attr { return msg.Attr{Key: KeyTranscationID, Value: id} }
+func Reminder(name string) msg.Attr    { return msg.Attr{Key: KeyReminder, Value: name} }
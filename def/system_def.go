@@ -4,7 +4,7 @@ const (
 	// Wildcard symbol represents routing to any actor of this type
 	SymbolWildcard = "?"
 
-	// Represents routing to a group of actors
+	// Represents routing to a group of actors, the group name follows the symbol (e.g. "#room_1001")
 	// - Note: This symbol can only be used with the send interface (asynchronous call)
 	SymbolGroup = "#"
 
@@ -15,6 +15,10 @@ const (
 	// Represents random routing to an actor of this type, but prioritizes actors on the current node
 	// If there are no actors of this type on the current node, it randomly selects from other nodes
 	SymbolLocalFirst = "~"
+
+	// Represents sticky routing by the routing key of the message header (msg.WithReqRoutingKey),
+	// the key is mapped onto the actors of this type through a consistent-hash ring, the same key lands on the same actor
+	SymbolHash = "%"
 )
 
 const (
@@ -23,4 +27,39 @@ const (
 	RedisAddressbookTyField = "braid.addressbook.ty."
 	// hash
 	RedisAddressbookNodesField = "braid.addressbook.nodes"
+	// hash, node -> total weight of its actors, read along with the sample of a wildcard pick
+	RedisAddressbookWeightsField = "braid.addressbook.weights"
+	// set
+	RedisAddressbookGroupField = "braid.addressbook.group."
+	// set, the group memberships of the actors of a node, json {group, member} of the exact member of the group set
+	RedisAddressbookNodeGroupsField = "braid.addressbook.node.groups."
+	// zset, node -> expiry of its lease (unix ms), refreshed by its heartbeat. Only the nodes listed here are reaped
+	RedisAddressbookLeasesField = "braid.addressbook.leases"
+	// zset, node down json -> sequence, the nodes reaped after their lease expired
+	RedisAddressbookDownField = "braid.addressbook.down"
+	// string, the sequence of the node down log
+	RedisAddressbookDownSeqField = "braid.addressbook.down.seq"
+	// pubsub channel, the register and unregister events which invalidate the address book caches of the nodes
+	RedisAddressbookEventChannel = "braid.addressbook.events"
+
+	// string, distributed lock held while an actor is activated on demand
+	RedisActivationLockField = "braid.activation."
+
+	// zset, reminder key -> next fire (unix ms), claimed fires are pushed back by their lease
+	RedisReminderDueField = "braid.reminder.due"
+	// hash, reminder key -> reminder json
+	RedisReminderDataField = "braid.reminder.data"
+	// set, reminder names of an actor
+	RedisReminderActorField = "braid.reminder.actor."
+	// string, marks a reminder fire as delivered
+	RedisReminderFiredField = "braid.reminder.fired."
+
+	// hash, watcher id -> watcher type of the actors watching an actor
+	RedisWatchField = "braid.watch."
+)
+
+const (
+	// ReminderIDPrefix is the prefix of the header id of the messages fired by reminders,
+	// the rest of the id identifies the fire, the poller deduplicates the deliveries by it
+	ReminderIDPrefix = "reminder:"
 )
@@ -127,6 +127,24 @@ func (c *Client) getConn(address string) (*grpc.ClientConn, error) {
 	return conn, nil
 }
 
+// Connect makes sure a connection to the address exists, creating it if needed
+func (c *Client) Connect(addr string) error {
+	if _, err := c.getConn(addr); err == nil {
+		return nil
+	}
+
+	conn, err := c.newconn(addr)
+	if err != nil {
+		return fmt.Errorf("[braid.client] failed to connect %s: %w", addr, err)
+	}
+
+	if prev, loaded := c.connmap.LoadOrStore(addr, conn); loaded && prev != conn {
+		// another goroutine connected first
+		c.closeconn(conn)
+	}
+	return nil
+}
+
 func (c *Client) CallWait(ctx context.Context, addr, methon string, args, reply interface{}, opts ...interface{}) error {
 
 	var grpcopts []grpc.CallOption
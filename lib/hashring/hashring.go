@@ -0,0 +1,75 @@
+package hashring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of virtual nodes of a member
+const DefaultReplicas = 160
+
+// Ring maps keys onto members through a consistent-hash ring, each member owns Replicas virtual nodes.
+// Adding or removing a member only remaps the keys of its own virtual nodes
+//
+//	a Ring is immutable once built, build a new one when the members change
+type Ring struct {
+	hashes  []uint32
+	owners  map[uint32]string
+	members []string
+}
+
+// New builds a ring of the members, replicas <= 0 uses DefaultReplicas
+func New(replicas int, members ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+
+	r := &Ring{
+		hashes:  make([]uint32, 0, replicas*len(members)),
+		owners:  make(map[uint32]string, replicas*len(members)),
+		members: append([]string{}, members...),
+	}
+	sort.Strings(r.members)
+
+	// members are placed in a stable order, so a hash collision is resolved the same way on every node
+	for _, member := range r.members {
+		for i := 0; i < replicas; i++ {
+			h := hash(member + "#" + strconv.Itoa(i))
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.owners[h] = member
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+
+	return r
+}
+
+// Get returns the member owning the key, false if the ring is empty
+func (r *Ring) Get(key string) (string, bool) {
+	if len(r.hashes) == 0 {
+		return "", false
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.owners[r.hashes[i]], true
+}
+
+// Members returns the sorted members of the ring
+func (r *Ring) Members() []string {
+	return append([]string{}, r.members...)
+}
+
+func hash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
@@ -0,0 +1,49 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	_, ok := New(0).Get("room")
+	assert.False(t, ok)
+
+	members := []string{"a", "b", "c", "d"}
+	ring := New(0, members...)
+
+	// the order of the members does not matter
+	other := New(0, "d", "c", "b", "a")
+
+	counts := make(map[string]int)
+	owners := make(map[string]string)
+	for i := 0; i < 10000; i++ {
+		key := "room_" + strconv.Itoa(i)
+		owner, ok := ring.Get(key)
+		assert.True(t, ok)
+
+		o, _ := other.Get(key)
+		assert.Equal(t, owner, o)
+
+		counts[owner]++
+		owners[key] = owner
+	}
+
+	// virtual nodes spread the keys
+	for _, m := range members {
+		assert.Greater(t, counts[m], 1500, m)
+	}
+
+	// removing a member only moves its own keys
+	smaller := New(0, "a", "b", "c")
+	for key, owner := range owners {
+		o, _ := smaller.Get(key)
+		if owner != "d" {
+			assert.Equal(t, owner, o)
+		} else {
+			assert.NotEqual(t, "d", o)
+		}
+	}
+}
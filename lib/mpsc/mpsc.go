@@ -20,6 +20,7 @@ type Queue struct {
 	head, tail *node
 	C          chan int32
 	count      int32
+	notify     func()
 }
 
 func New() *Queue {
@@ -46,6 +47,17 @@ func (q *Queue) Push(x interface{}) {
 	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&prev.next)), unsafe.Pointer(n))
 
 	q.setCount(1)
+
+	if q.notify != nil {
+		q.notify()
+	}
+}
+
+// Notify registers a callback called after each Push, for consumers which are not waiting on C
+//
+// Notify must be called before the queue is shared with the producers
+func (q *Queue) Notify(f func()) {
+	q.notify = f
 }
 
 // Pop removes the item from the front of the queue or nil if the queue is empty
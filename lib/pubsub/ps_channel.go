@@ -99,7 +99,6 @@ func (c *Channel) addHandlers(queue *mpsc.Queue) {
 			mb := msg.NewBuilder(context.TODO()).
 				WithReqHeader(&router.Header{ID: recvmsg.Header.ID, Event: recvmsg.Header.Event}).
 				WithReqBody(recvmsg.Body).Build()
-			mb.GetWg().Add(1)
 			queue.Push(mb)
 
 			pipe.XAck(context.TODO(), c.topic, c.channel, recvmsg.Header.ID)
@@ -0,0 +1,145 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CronExpress is a parsed cron expression
+//
+//	fields: second minute hour day month weekday, each field accepts "*" "*/5" "1,2,3,5-8"
+//	the standard 5 field form (without seconds) is also accepted and fires at second 0
+type CronExpress struct {
+	second  *ExpressSet
+	minute  *ExpressSet
+	hour    *ExpressSet
+	day     *ExpressSet
+	month   *ExpressSet
+	weekday *ExpressSet
+
+	rawExpress string
+}
+
+// cronSearchYears bounds the search of the next fire (e.g. "0 0 0 29 2 1" only matches every 28 years)
+const cronSearchYears = 30
+
+// ParseCron parses a cron expression
+func ParseCron(express string) (*CronExpress, error) {
+	fields := strings.Fields(express)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("cron express %q expects 5 or 6 fields", express)
+	}
+
+	types := []string{
+		ExpressType_Second,
+		ExpressType_Minute,
+		ExpressType_Hour,
+		ExpressType_Day,
+		ExpressType_Month,
+		ExpressType_WeekDay,
+	}
+
+	sets := make([]*ExpressSet, len(types))
+	for i, ty := range types {
+		sets[i] = parseExpress(fields[i], ty)
+		if sets[i] == nil || len(sets[i].timeMap) == 0 {
+			return nil, fmt.Errorf("cron express %q invalid %v field %q", express, ty, fields[i])
+		}
+	}
+
+	return &CronExpress{
+		second:     sets[0],
+		minute:     sets[1],
+		hour:       sets[2],
+		day:        sets[3],
+		month:      sets[4],
+		weekday:    sets[5],
+		rawExpress: express,
+	}, nil
+}
+
+func (c *CronExpress) String() string {
+	return c.rawExpress
+}
+
+// IsMatch checks whether every field of the expression matches t (in the location of t)
+func (c *CronExpress) IsMatch(t time.Time) bool {
+	return c.month.IsMatch(t) && c.day.IsMatch(t) && c.weekday.IsMatch(t) &&
+		c.hour.IsMatch(t) && c.minute.IsMatch(t) && c.second.IsMatch(t)
+}
+
+// Next returns the first time after the given time that matches the expression, in the location of after
+//
+//	DST transitions:
+//	- the hours skipped when clocks go forward still fire once, right after the transition
+//	- the hour repeated when clocks go back fires only once
+//
+// returns the zero time if nothing matches within cronSearchYears
+func (c *CronExpress) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Second).Add(time.Second)
+	limit := after.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !c.month.IsMatch(t) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !c.day.IsMatch(t) || !c.weekday.IsMatch(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !c.hour.IsMatch(t) {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+
+			// hours skipped by a DST transition
+			if next.Day() == t.Day() {
+				for h := t.Hour() + 1; h < next.Hour(); h++ {
+					if _, ok := c.hour.timeMap[h]; ok && wallAfter(next, after) {
+						return next
+					}
+				}
+			}
+
+			t = next
+			continue
+		}
+
+		// minutes and seconds move on the absolute time, so that a repeated hour is walked through only once
+		if !c.minute.IsMatch(t) {
+			t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+			continue
+		}
+
+		if !c.second.IsMatch(t) {
+			t = t.Add(time.Second)
+			continue
+		}
+
+		// the same wall clock time occurs twice when clocks go back
+		if !wallAfter(t, after) {
+			t = t.Add(time.Second)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// wallAfter compares the wall clock of two times, ignoring their UTC offsets
+func wallAfter(t, u time.Time) bool {
+	wt := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	wu := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, time.UTC)
+	return wt.After(wu)
+}
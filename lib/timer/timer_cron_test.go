@@ -0,0 +1,69 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	_, err := ParseCron("0 0 5 * * *")
+	assert.Nil(t, err)
+
+	_, err = ParseCron("30 4 * * 1")
+	assert.Nil(t, err)
+
+	_, err = ParseCron("0 0 5 * *")
+	assert.Nil(t, err) // 5 fields, seconds omitted
+
+	_, err = ParseCron("0 0 *")
+	assert.NotNil(t, err)
+
+	_, err = ParseCron("0 0 a * * *")
+	assert.NotNil(t, err)
+}
+
+func TestCronNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.Nil(t, err)
+
+	// daily reset at 05:00
+	daily, _ := ParseCron("0 0 5 * * *")
+	after := time.Date(2024, 6, 1, 5, 0, 0, 0, shanghai)
+	assert.Equal(t, time.Date(2024, 6, 2, 5, 0, 0, 0, shanghai), daily.Next(after))
+	assert.Equal(t, time.Date(2024, 6, 1, 5, 0, 0, 0, shanghai), daily.Next(after.Add(-time.Second)))
+
+	// weekly event on monday 20:30
+	weekly, _ := ParseCron("0 30 20 * * 1")
+	assert.Equal(t, time.Date(2024, 6, 3, 20, 30, 0, 0, shanghai), weekly.Next(after))
+
+	// every 15 seconds
+	every, _ := ParseCron("*/15 * * * * *")
+	assert.Equal(t, time.Date(2024, 6, 1, 5, 0, 15, 0, shanghai), every.Next(after))
+
+	// the same expression is evaluated in the given time zone
+	utcNext := daily.Next(after.In(time.UTC))
+	assert.Equal(t, 5, utcNext.Hour())
+	assert.Equal(t, time.UTC, utcNext.Location())
+}
+
+func TestCronNextDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// 2024-03-10 02:00 EST jumps to 03:00 EDT, the 02:30 fire runs once right after the transition
+	spring, _ := ParseCron("0 30 2 * * *")
+	next := spring.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, ny), next)
+	assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, ny), spring.Next(next))
+
+	// 2024-11-03 02:00 EDT goes back to 01:00 EST, the 01:30 fire runs only once
+	fall, _ := ParseCron("0 30 1 * * *")
+	first := fall.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, ny))
+	assert.Equal(t, 1, first.Hour())
+	assert.Equal(t, 30, first.Minute())
+
+	second := fall.Next(first)
+	assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, ny), second)
+}
@@ -0,0 +1,225 @@
+// Package timewheel provides a hierarchical timing wheel.
+//
+// A single goroutine drives every timer of the wheel, scheduling and stopping a timer are O(1).
+// Level 0 holds the timers expiring within one revolution (tick * slots), each higher level covers
+// slots times the span of the level below. When a lower level wraps around, the current slot of the
+// next level is cascaded down, so a timer is moved at most once per level.
+package timewheel
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTick is the resolution of the wheel
+	DefaultTick = 10 * time.Millisecond
+
+	// DefaultSlotBits is the number of slots per level as a power of two (64)
+	DefaultSlotBits = 6
+)
+
+// Timer is a timer scheduled on a TimingWheel
+type Timer struct {
+	tw *TimingWheel
+
+	expire int64 // absolute tick at which the timer fires
+	period int64 // in ticks, 0 for one-shot timers
+	f      func()
+
+	bucket *list.List
+	elem   *list.Element
+}
+
+// TimingWheel is a hierarchical timing wheel driven by a single goroutine
+type TimingWheel struct {
+	tick  time.Duration
+	bits  uint
+	mask  int64
+	start time.Time
+
+	sync.Mutex
+	current int64 // last tick processed
+	levels  [][]*list.List
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a timing wheel with the given resolution and 2^slotBits slots per level
+func New(tick time.Duration, slotBits uint) *TimingWheel {
+	if tick <= 0 {
+		tick = DefaultTick
+	}
+	if slotBits == 0 {
+		slotBits = DefaultSlotBits
+	}
+
+	return &TimingWheel{
+		tick:   tick,
+		bits:   slotBits,
+		mask:   int64(1)<<slotBits - 1,
+		start:  time.Now(),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start runs the goroutine which drives the wheel
+func (tw *TimingWheel) Start() {
+	go func() {
+		ticker := time.NewTicker(tw.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				tw.advance(int64(time.Since(tw.start) / tw.tick))
+			case <-tw.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the wheel, pending timers never fire
+func (tw *TimingWheel) Stop() {
+	tw.stopOnce.Do(func() {
+		close(tw.stopCh)
+	})
+}
+
+// AfterFunc schedules f to run after due, and then every period if period > 0
+//
+//	f runs on the wheel goroutine and must not block, it should only hand the expiration over (e.g. to a mailbox)
+func (tw *TimingWheel) AfterFunc(due, period time.Duration, f func()) *Timer {
+	t := &Timer{tw: tw, f: f}
+	t.Reset(due, period)
+	return t
+}
+
+// Stop prevents the timer from firing again
+//
+//	returns false if the timer was not scheduled (already fired one-shot or already stopped)
+func (t *Timer) Stop() bool {
+	t.tw.Lock()
+	defer t.tw.Unlock()
+
+	return t.tw.remove(t)
+}
+
+// Reset reschedules the timer to fire after due, and then every period if period > 0
+func (t *Timer) Reset(due, period time.Duration) bool {
+	tw := t.tw
+
+	tw.Lock()
+	tw.remove(t)
+	t.expire = tw.ticks(time.Since(tw.start) + due)
+	t.period = 0
+	if period > 0 {
+		t.period = tw.ticks(period)
+	}
+	fire := tw.add(t)
+	tw.Unlock()
+
+	if fire {
+		t.f()
+	}
+	return true
+}
+
+// ticks converts a duration to a number of ticks, rounded up
+func (tw *TimingWheel) ticks(d time.Duration) int64 {
+	return int64((d + tw.tick - 1) / tw.tick)
+}
+
+// add puts the timer into the slot matching its expiration, returns true if it is already due
+func (tw *TimingWheel) add(t *Timer) bool {
+	delta := t.expire - tw.current
+	if delta <= 0 {
+		if t.period > 0 {
+			// keep the cadence of periodic timers, unless the wheel fell behind by more than a period
+			t.expire += t.period
+			if t.expire <= tw.current {
+				t.expire = tw.current + t.period
+			}
+			tw.add(t)
+		}
+		return true
+	}
+
+	level := 0
+	for delta>>(tw.bits*uint(level+1)) > 0 {
+		level++
+	}
+	for len(tw.levels) <= level {
+		slots := make([]*list.List, tw.mask+1)
+		for i := range slots {
+			slots[i] = list.New()
+		}
+		tw.levels = append(tw.levels, slots)
+	}
+
+	slot := (t.expire >> (tw.bits * uint(level))) & tw.mask
+	t.bucket = tw.levels[level][slot]
+	t.elem = t.bucket.PushBack(t)
+
+	return false
+}
+
+func (tw *TimingWheel) remove(t *Timer) bool {
+	if t.bucket == nil {
+		return false
+	}
+
+	t.bucket.Remove(t.elem)
+	t.bucket, t.elem = nil, nil
+	return true
+}
+
+// advance processes every tick up to target and runs the expired timers
+func (tw *TimingWheel) advance(target int64) {
+	var expired []*Timer
+
+	tw.Lock()
+	for tw.current < target {
+		tw.current++
+
+		// cascade the higher levels whose lower level wrapped around
+		for level := 1; level < len(tw.levels); level++ {
+			if tw.current&(int64(1)<<(tw.bits*uint(level))-1) != 0 {
+				break
+			}
+
+			slot := (tw.current >> (tw.bits * uint(level))) & tw.mask
+			expired = tw.flush(tw.levels[level][slot], expired)
+		}
+
+		if len(tw.levels) > 0 {
+			expired = tw.flush(tw.levels[0][tw.current&tw.mask], expired)
+		}
+	}
+	tw.Unlock()
+
+	for _, t := range expired {
+		t.f()
+	}
+}
+
+// flush empties a bucket, re-adding its timers to a lower level or collecting the expired ones
+func (tw *TimingWheel) flush(bucket *list.List, expired []*Timer) []*Timer {
+	for e := bucket.Front(); e != nil; {
+		next := e.Next()
+
+		t := e.Value.(*Timer)
+		bucket.Remove(e)
+		t.bucket, t.elem = nil, nil
+
+		if tw.add(t) {
+			expired = append(expired, t)
+		}
+
+		e = next
+	}
+	return expired
+}
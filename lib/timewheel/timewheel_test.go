@@ -0,0 +1,186 @@
+package timewheel
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneShot(t *testing.T) {
+	tw := New(time.Millisecond*10, 6)
+	tw.Start()
+	defer tw.Stop()
+
+	var fired int32
+	begin := time.Now()
+	done := make(chan time.Duration, 1)
+
+	tw.AfterFunc(time.Millisecond*100, 0, func() {
+		atomic.AddInt32(&fired, 1)
+		done <- time.Since(begin)
+	})
+
+	elapsed := <-done
+	assert.True(t, elapsed >= time.Millisecond*90, elapsed)
+
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fired))
+}
+
+func TestPeriodic(t *testing.T) {
+	tw := New(time.Millisecond*10, 6)
+	tw.Start()
+	defer tw.Stop()
+
+	var fired int32
+	timer := tw.AfterFunc(0, time.Millisecond*50, func() {
+		atomic.AddInt32(&fired, 1)
+	})
+
+	time.Sleep(time.Millisecond * 520)
+	assert.True(t, timer.Stop())
+
+	cnt := atomic.LoadInt32(&fired)
+	assert.True(t, cnt >= 10 && cnt <= 12, cnt)
+
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, cnt, atomic.LoadInt32(&fired))
+	assert.False(t, timer.Stop())
+}
+
+func TestResetAndStop(t *testing.T) {
+	tw := New(time.Millisecond*10, 6)
+	tw.Start()
+	defer tw.Stop()
+
+	var fired int32
+	timer := tw.AfterFunc(time.Millisecond*100, 0, func() {
+		atomic.AddInt32(&fired, 1)
+	})
+
+	// pushed further away before it fires
+	time.Sleep(time.Millisecond * 50)
+	timer.Reset(time.Millisecond*200, 0)
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fired))
+
+	time.Sleep(time.Millisecond * 150)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fired))
+
+	stopped := tw.AfterFunc(time.Millisecond*50, 0, func() {
+		atomic.AddInt32(&fired, 1)
+	})
+	assert.True(t, stopped.Stop())
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fired))
+}
+
+func TestCascade(t *testing.T) {
+	// 1ms ticks and 4 slots per level, a 300ms timer goes through 4 levels
+	tw := New(time.Millisecond, 2)
+	tw.Start()
+	defer tw.Stop()
+
+	var wg sync.WaitGroup
+	begin := time.Now()
+
+	for _, due := range []time.Duration{3, 17, 70, 300} {
+		due := due * time.Millisecond
+		wg.Add(1)
+		tw.AfterFunc(due, 0, func() {
+			defer wg.Done()
+			elapsed := time.Since(begin)
+			assert.True(t, elapsed >= due-time.Millisecond, "due %v elapsed %v", due, elapsed)
+			assert.True(t, elapsed < due+time.Millisecond*50, "due %v elapsed %v", due, elapsed)
+		})
+	}
+
+	wg.Wait()
+}
+
+// go test -benchmem -run=^$ -bench . github.com/pojol/braid/lib/timewheel
+//
+// BenchmarkTimingWheel schedules and stops timers on a shared wheel,
+// BenchmarkTickerGoroutine does the same with the previous approach of one goroutine and ticker per timer
+func BenchmarkTimingWheel(b *testing.B) {
+	tw := New(DefaultTick, DefaultSlotBits)
+	tw.Start()
+	defer tw.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := tw.AfterFunc(time.Second, time.Second, func() {})
+		t.Stop()
+	}
+}
+
+func BenchmarkTickerGoroutine(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stop := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+				case <-stop:
+					return
+				}
+			}
+		}()
+		close(stop)
+	}
+}
+
+// BenchmarkTimingWheelActive keeps 10000 periodic timers alive, as a node full of player actors would
+func BenchmarkTimingWheelActive(b *testing.B) {
+	tw := New(DefaultTick, DefaultSlotBits)
+	tw.Start()
+	defer tw.Stop()
+
+	var fired int64
+	for i := 0; i < 10000; i++ {
+		tw.AfterFunc(time.Millisecond*time.Duration(i%100), time.Millisecond*100, func() {
+			atomic.AddInt64(&fired, 1)
+		})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&fired))/b.Elapsed().Seconds(), "fires/s")
+}
+
+func BenchmarkTickerGoroutineActive(b *testing.B) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var fired int64
+	for i := 0; i < 10000; i++ {
+		go func() {
+			ticker := time.NewTicker(time.Millisecond * 100)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					atomic.AddInt64(&fired, 1)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&fired))/b.Elapsed().Seconds(), "fires/s")
+}
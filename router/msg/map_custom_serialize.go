@@ -2,7 +2,9 @@ package msg
 
 import (
 	"encoding/json"
+	fmt "fmt"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -32,3 +34,23 @@ func (c *CustomObjectSerialize) Decode(data []byte, v any) error {
 func (c *CustomObjectSerialize) Encode(v any) ([]byte, error) {
 	return msgpack.Marshal(v)
 }
+
+// ProtoSerialize encodes protobuf messages, the values must implement proto.Message
+type ProtoSerialize struct {
+}
+
+func (c *ProtoSerialize) Decode(data []byte, v any) error {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("proto decode %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, pm)
+}
+
+func (c *ProtoSerialize) Encode(v any) ([]byte, error) {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto encode %T is not a proto.Message", v)
+	}
+	return proto.Marshal(pm)
+}
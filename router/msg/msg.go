@@ -2,9 +2,10 @@ package msg
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"github.com/google/uuid"
-	"github.com/pojol/braid/lib/warpwaitgroup"
 	"github.com/pojol/braid/router"
 )
 
@@ -23,8 +24,15 @@ type Wrapper struct {
 	Ctx context.Context
 	Err error
 
-	parm WrapperParm
-	Done chan struct{} // Used for synchronization
+	parm  WrapperParm
+	reply *reply // set by ExpectReply for a synchronous call
+}
+
+// reply completes a synchronous call, only the first Reply counts
+type reply struct {
+	once     sync.Once
+	done     chan struct{}
+	deferred int32
 }
 
 // NewMessage create new message
@@ -37,8 +45,6 @@ func newMessage(uid string) *router.Message {
 	return m
 }
 
-type WaitGroupKey struct{}
-
 // MsgWrapperBuilder used to build MsgWrapper
 type MsgBuilder struct {
 	wrapper *Wrapper
@@ -52,12 +58,6 @@ func NewBuilder(ctx context.Context, opts ...WrapperOption) *MsgBuilder {
 		CustomObjSerialize: &CustomObjectSerialize{},
 	}
 
-	if wc, ok := ctx.Value(WaitGroupKey{}).(*warpwaitgroup.WrapWaitGroup); ok {
-		ctx = context.WithValue(ctx, WaitGroupKey{}, wc)
-	} else {
-		ctx = context.WithValue(ctx, WaitGroupKey{}, &warpwaitgroup.WrapWaitGroup{})
-	}
-
 	return &MsgBuilder{
 		wrapper: &Wrapper{
 			parm: parm,
@@ -69,16 +69,25 @@ func NewBuilder(ctx context.Context, opts ...WrapperOption) *MsgBuilder {
 }
 
 func Swap(mw *Wrapper) *Wrapper {
-
-	ctx := context.WithValue(mw.Ctx, WaitGroupKey{}, &warpwaitgroup.WrapWaitGroup{})
-
 	return &Wrapper{
-		Ctx: ctx,
+		Ctx: mw.Ctx,
 		// 交换 Req 和 Res
 		parm: mw.parm,
 		Req:  mw.Req,
 		Res:  mw.Res,
-		Done: make(chan struct{}),
+	}
+}
+
+// Clone returns a copy of the wrapper with its own request header and an empty response,
+// used when the same message is delivered to several actors
+func Clone(mw *Wrapper) *Wrapper {
+	header := *mw.Req.Header
+
+	return &Wrapper{
+		Ctx:  mw.Ctx,
+		parm: mw.parm,
+		Req:  &router.Message{Header: &header, Body: mw.Req.Body},
+		Res:  newMessage(header.ID),
 	}
 }
 
@@ -94,6 +103,10 @@ func (b *MsgBuilder) WithReqHeader(h *router.Header) *MsgBuilder {
 		b.wrapper.Req.Header.TargetActorID = h.TargetActorID
 		b.wrapper.Req.Header.TargetActorType = h.TargetActorType
 		b.wrapper.Req.Header.Custom = h.Custom
+		b.wrapper.Req.Header.Priority = h.Priority
+		b.wrapper.Req.Header.CallPath = h.CallPath
+		b.wrapper.Req.Header.Deadline = h.Deadline
+		b.wrapper.Req.Header.RoutingKey = h.RoutingKey
 	} else {
 		// If either header is nil, directly set the header
 		b.wrapper.Req.Header = h
@@ -101,6 +114,27 @@ func (b *MsgBuilder) WithReqHeader(h *router.Header) *MsgBuilder {
 	return b
 }
 
+// Request priorities, a high priority request is handled by the actor before its queued normal messages
+//
+//	it is meant for control messages (admin commands, kick a player ...) which must not wait behind a flood of user messages
+const (
+	PriorityNormal int32 = 0
+	PriorityHigh   int32 = 1
+)
+
+// WithReqPriority sets the priority of the request, see PriorityHigh
+func (b *MsgBuilder) WithReqPriority(priority int32) *MsgBuilder {
+	b.wrapper.Req.Header.Priority = priority
+	return b
+}
+
+// WithReqRoutingKey sets the key the request is routed by with the consistent-hash symbol (def.SymbolHash),
+// the requests with the same key land on the same actor
+func (b *MsgBuilder) WithReqRoutingKey(key string) *MsgBuilder {
+	b.wrapper.Req.Header.RoutingKey = key
+	return b
+}
+
 func (b *MsgBuilder) WithReqBody(byt []byte) *MsgBuilder {
 	b.wrapper.Req.Body = byt
 	return b
@@ -134,9 +168,49 @@ func (mw *Wrapper) ToBuilder() *MsgBuilder {
 
 ////////
 
-func (mw *Wrapper) GetWg() *warpwaitgroup.WrapWaitGroup {
-	if wc, ok := mw.Ctx.Value(WaitGroupKey{}).(*warpwaitgroup.WrapWaitGroup); ok {
-		return wc
+// ExpectReply prepares the wrapper for a synchronous call, the caller waits on Replied.
+// A pending reply is never replaced, it returns false if the wrapper is already in flight in a call
+// which was not answered yet (e.g. a handler which forwards its message), the call is then made on a Clone
+func (mw *Wrapper) ExpectReply() bool {
+	if r := mw.reply; r != nil {
+		select {
+		case <-r.done:
+		default:
+			return false
+		}
+	}
+
+	mw.reply = &reply{done: make(chan struct{})}
+	return true
+}
+
+// Reply answers the call with the response of the wrapper (Res and Err),
+// it is called once the handler returned unless the handler deferred its reply.
+// Only the first reply counts, a message which was sent and not called ignores it
+func (mw *Wrapper) Reply() {
+	if r := mw.reply; r != nil {
+		r.once.Do(func() { close(r.done) })
+	}
+}
+
+// DeferReply keeps the call open once the handler returned, the handler (or a continuation of it,
+// e.g. the Then of a ReenterCall) answers it later with Reply
+func (mw *Wrapper) DeferReply() {
+	if r := mw.reply; r != nil {
+		atomic.StoreInt32(&r.deferred, 1)
+	}
+}
+
+// ReplyDeferred returns true if the handler deferred its reply
+func (mw *Wrapper) ReplyDeferred() bool {
+	r := mw.reply
+	return r != nil && atomic.LoadInt32(&r.deferred) != 0
+}
+
+// Replied is closed once the call is answered, it is nil if the wrapper does not expect a reply
+func (mw *Wrapper) Replied() <-chan struct{} {
+	if r := mw.reply; r != nil {
+		return r.done
 	}
 	return nil
 }
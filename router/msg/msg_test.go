@@ -41,3 +41,53 @@ func TestObjectSerialize(t *testing.T) {
 	assert.Equal(t, obj.Probability, 1.11)
 	assert.Equal(t, obj.Lst, []string{"a", "b", "c"})
 }
+
+func TestProtoSerialize(t *testing.T) {
+	codec := &ProtoSerialize{}
+
+	byt, err := codec.Encode(&router.Header{Event: "typed", Timestamp: 10, Priority: PriorityHigh, CallPath: []string{"a", "b"}, Deadline: 42, RoutingKey: "room"})
+	assert.Nil(t, err)
+
+	h := &router.Header{}
+	assert.Nil(t, codec.Decode(byt, h))
+	assert.Equal(t, "typed", h.Event)
+	assert.Equal(t, int64(10), h.Timestamp)
+	assert.Equal(t, PriorityHigh, h.Priority)
+	assert.Equal(t, []string{"a", "b"}, h.CallPath)
+	assert.Equal(t, int64(42), h.Deadline)
+	assert.Equal(t, "room", h.RoutingKey)
+
+	_, err = codec.Encode(&testObj{})
+	assert.NotNil(t, err)
+}
+
+func TestReply(t *testing.T) {
+	mw := NewBuilder(context.TODO()).Build()
+
+	// a sent message does not expect a reply
+	mw.Reply()
+	mw.DeferReply()
+	assert.Nil(t, mw.Replied())
+	assert.False(t, mw.ReplyDeferred())
+
+	mw.ExpectReply()
+	mw.DeferReply()
+	assert.True(t, mw.ReplyDeferred())
+
+	// a pending reply is not replaced by a nested call
+	done := mw.Replied()
+	assert.False(t, mw.ExpectReply())
+	assert.Equal(t, done, mw.Replied())
+
+	mw.Reply()
+	mw.Reply()
+	select {
+	case <-mw.Replied():
+	default:
+		t.Fatal("reply did not complete the call")
+	}
+
+	// once replied the wrapper can be called again
+	assert.True(t, mw.ExpectReply())
+	assert.NotEqual(t, done, mw.Replied())
+}
@@ -27,16 +27,22 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type Header struct {
-	ID              string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	OrgActorID      string `protobuf:"bytes,2,opt,name=OrgActorID,proto3" json:"OrgActorID,omitempty"`
-	OrgActorType    string `protobuf:"bytes,3,opt,name=OrgActorType,proto3" json:"OrgActorType,omitempty"`
-	PrevActorType   string `protobuf:"bytes,4,opt,name=PrevActorType,proto3" json:"PrevActorType,omitempty"`
-	TargetActorID   string `protobuf:"bytes,7,opt,name=TargetActorID,proto3" json:"TargetActorID,omitempty"`
-	TargetActorType string `protobuf:"bytes,8,opt,name=TargetActorType,proto3" json:"TargetActorType,omitempty"`
-	Event           string `protobuf:"bytes,9,opt,name=Event,proto3" json:"Event,omitempty"`
-	Token           string `protobuf:"bytes,10,opt,name=Token,proto3" json:"Token,omitempty"`
-	Timestamp       int64  `protobuf:"varint,11,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
-	Custom          []byte `protobuf:"bytes,12,opt,name=Custom,proto3" json:"Custom,omitempty"`
+	ID              string   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	OrgActorID      string   `protobuf:"bytes,2,opt,name=OrgActorID,proto3" json:"OrgActorID,omitempty"`
+	OrgActorType    string   `protobuf:"bytes,3,opt,name=OrgActorType,proto3" json:"OrgActorType,omitempty"`
+	PrevActorType   string   `protobuf:"bytes,4,opt,name=PrevActorType,proto3" json:"PrevActorType,omitempty"`
+	TargetActorID   string   `protobuf:"bytes,7,opt,name=TargetActorID,proto3" json:"TargetActorID,omitempty"`
+	TargetActorType string   `protobuf:"bytes,8,opt,name=TargetActorType,proto3" json:"TargetActorType,omitempty"`
+	Event           string   `protobuf:"bytes,9,opt,name=Event,proto3" json:"Event,omitempty"`
+	Token           string   `protobuf:"bytes,10,opt,name=Token,proto3" json:"Token,omitempty"`
+	Timestamp       int64    `protobuf:"varint,11,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	Custom          []byte   `protobuf:"bytes,12,opt,name=Custom,proto3" json:"Custom,omitempty"`
+	Err             string   `protobuf:"bytes,13,opt,name=Err,proto3" json:"Err,omitempty"`
+	Priority        int32    `protobuf:"varint,14,opt,name=Priority,proto3" json:"Priority,omitempty"`
+	CallPath        []string `protobuf:"bytes,15,rep,name=CallPath,proto3" json:"CallPath,omitempty"`
+	Deadline        int64    `protobuf:"varint,16,opt,name=Deadline,proto3" json:"Deadline,omitempty"`
+	RoutingKey      string   `protobuf:"bytes,17,opt,name=RoutingKey,proto3" json:"RoutingKey,omitempty"`
+	Targets         []string `protobuf:"bytes,18,rep,name=Targets,proto3" json:"Targets,omitempty"`
 }
 
 func (m *Header) Reset()         { *m = Header{} }
@@ -142,6 +148,48 @@ func (m *Header) GetCustom() []byte {
 	return nil
 }
 
+func (m *Header) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
+func (m *Header) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *Header) GetCallPath() []string {
+	if m != nil {
+		return m.CallPath
+	}
+	return nil
+}
+
+func (m *Header) GetDeadline() int64 {
+	if m != nil {
+		return m.Deadline
+	}
+	return 0
+}
+
+func (m *Header) GetRoutingKey() string {
+	if m != nil {
+		return m.RoutingKey
+	}
+	return ""
+}
+
+func (m *Header) GetTargets() []string {
+	if m != nil {
+		return m.Targets
+	}
+	return nil
+}
+
 type Message struct {
 	Header *Header `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Body   []byte  `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
@@ -292,29 +340,34 @@ func init() {
 func init() { proto.RegisterFile("router.proto", fileDescriptor_367072455c71aedc) }
 
 var fileDescriptor_367072455c71aedc = []byte{
-	// 348 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xcf, 0x6a, 0xea, 0x40,
-	0x14, 0xc6, 0x93, 0xe8, 0x8d, 0x7a, 0xcc, 0xd5, 0xcb, 0xe1, 0x52, 0x86, 0x52, 0x06, 0x1b, 0x4a,
-	0xc9, 0xa6, 0x16, 0xec, 0xb2, 0x2b, 0x5b, 0x85, 0xba, 0x28, 0x2d, 0x43, 0x5e, 0x20, 0xea, 0x90,
-	0x4a, 0x89, 0x93, 0xce, 0x8c, 0x82, 0x6f, 0xd1, 0x87, 0xe9, 0x43, 0x74, 0xe9, 0xb2, 0xcb, 0xa2,
-	0x2f, 0x52, 0x32, 0x49, 0xf0, 0x0f, 0x74, 0x37, 0xdf, 0xef, 0xfc, 0x38, 0x07, 0x3e, 0x06, 0x3c,
-	0x29, 0x16, 0x9a, 0xcb, 0x6e, 0x2a, 0x85, 0x16, 0xe8, 0xe6, 0xc9, 0xff, 0x70, 0xc0, 0x7d, 0xe0,
-	0xd1, 0x94, 0x4b, 0x6c, 0x81, 0x33, 0x1a, 0x10, 0xbb, 0x63, 0x07, 0x0d, 0xe6, 0x8c, 0x06, 0x48,
-	0x01, 0x9e, 0x64, 0xdc, 0x9f, 0x68, 0x21, 0x47, 0x03, 0xe2, 0x18, 0xbe, 0x47, 0xd0, 0x07, 0xaf,
-	0x4c, 0xe1, 0x2a, 0xe5, 0xa4, 0x62, 0x8c, 0x03, 0x86, 0x17, 0xf0, 0xf7, 0x59, 0xf2, 0xe5, 0x4e,
-	0xaa, 0x1a, 0xe9, 0x10, 0x66, 0x56, 0x18, 0xc9, 0x98, 0xeb, 0xf2, 0x58, 0x2d, 0xb7, 0x0e, 0x20,
-	0x06, 0xd0, 0xde, 0x03, 0x66, 0x5b, 0xdd, 0x78, 0xc7, 0x18, 0xff, 0xc3, 0x9f, 0xe1, 0x92, 0xcf,
-	0x35, 0x69, 0x98, 0x79, 0x1e, 0x32, 0x1a, 0x8a, 0x57, 0x3e, 0x27, 0x90, 0x53, 0x13, 0xf0, 0x0c,
-	0x1a, 0xe1, 0x2c, 0xe1, 0x4a, 0x47, 0x49, 0x4a, 0x9a, 0x1d, 0x3b, 0xa8, 0xb0, 0x1d, 0xc0, 0x13,
-	0x70, 0xef, 0x17, 0x4a, 0x8b, 0x84, 0x78, 0x1d, 0x3b, 0xf0, 0x58, 0x91, 0xfc, 0x21, 0xd4, 0x1e,
-	0xb9, 0x52, 0x51, 0xcc, 0xf1, 0x12, 0xdc, 0x17, 0x53, 0xa0, 0xa9, 0xae, 0xd9, 0x6b, 0x75, 0x8b,
-	0xa2, 0xf3, 0x5a, 0x59, 0x31, 0x45, 0x84, 0xea, 0x58, 0x4c, 0x57, 0xa6, 0x48, 0x8f, 0x99, 0xb7,
-	0x7f, 0x05, 0x75, 0x23, 0x33, 0xfe, 0x86, 0xe7, 0x50, 0x49, 0x54, 0x5c, 0x2c, 0x69, 0x97, 0x4b,
-	0x8a, 0x2b, 0x2c, 0x9b, 0xed, 0xe9, 0xaa, 0xd4, 0x9d, 0xdf, 0xf5, 0xde, 0x2d, 0xd4, 0xfb, 0x93,
-	0x09, 0x4f, 0xb5, 0x90, 0x78, 0x0d, 0xb5, 0x4c, 0x99, 0xcd, 0x63, 0xfc, 0x57, 0xca, 0xe5, 0xe9,
-	0xd3, 0x63, 0xa2, 0x7c, 0xeb, 0x8e, 0x7c, 0x6e, 0xa8, 0xbd, 0xde, 0x50, 0xfb, 0x7b, 0x43, 0xed,
-	0xf7, 0x2d, 0xb5, 0xd6, 0x5b, 0x6a, 0x7d, 0x6d, 0xa9, 0x35, 0x76, 0xcd, 0x0f, 0xba, 0xf9, 0x09,
-	0x00, 0x00, 0xff, 0xff, 0x37, 0xd1, 0x96, 0xf6, 0x51, 0x02, 0x00, 0x00,
+	// 425 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xcf, 0x6a, 0xdb, 0x40,
+	0x10, 0xc6, 0x2d, 0x2b, 0x91, 0xed, 0x89, 0x62, 0xbb, 0x43, 0x29, 0x4b, 0x28, 0x42, 0x15, 0xa5,
+	0xe8, 0xd2, 0x14, 0xd2, 0x63, 0x4f, 0x69, 0x6c, 0xa8, 0x29, 0xa5, 0x66, 0xf1, 0x0b, 0x28, 0xf6,
+	0xa0, 0x88, 0xda, 0x5e, 0x77, 0xb5, 0x0e, 0xe8, 0x2d, 0xfa, 0x58, 0x3d, 0xe6, 0xd0, 0x43, 0x8f,
+	0xc5, 0x7e, 0x91, 0xa2, 0x59, 0xc9, 0xff, 0x20, 0x37, 0x7d, 0xbf, 0xf9, 0x31, 0x83, 0xf8, 0x16,
+	0x7c, 0xad, 0xd6, 0x86, 0xf4, 0xf5, 0x4a, 0x2b, 0xa3, 0xd0, 0xb3, 0x29, 0xfa, 0xe3, 0x82, 0xf7,
+	0x85, 0x92, 0x19, 0x69, 0xec, 0x42, 0x73, 0x34, 0x10, 0x4e, 0xe8, 0xc4, 0x1d, 0xd9, 0x1c, 0x0d,
+	0x30, 0x00, 0xf8, 0xae, 0xd3, 0xdb, 0xa9, 0x51, 0x7a, 0x34, 0x10, 0x4d, 0xe6, 0x07, 0x04, 0x23,
+	0xf0, 0xeb, 0x34, 0x29, 0x56, 0x24, 0x5c, 0x36, 0x8e, 0x18, 0xbe, 0x85, 0xcb, 0xb1, 0xa6, 0xc7,
+	0xbd, 0x74, 0xc6, 0xd2, 0x31, 0x2c, 0xad, 0x49, 0xa2, 0x53, 0x32, 0xf5, 0xb1, 0x96, 0xb5, 0x8e,
+	0x20, 0xc6, 0xd0, 0x3b, 0x00, 0xbc, 0xad, 0xcd, 0xde, 0x29, 0xc6, 0x97, 0x70, 0x3e, 0x7c, 0xa4,
+	0xa5, 0x11, 0x1d, 0x9e, 0xdb, 0x50, 0xd2, 0x89, 0xfa, 0x41, 0x4b, 0x01, 0x96, 0x72, 0xc0, 0xd7,
+	0xd0, 0x99, 0x64, 0x0b, 0xca, 0x4d, 0xb2, 0x58, 0x89, 0x8b, 0xd0, 0x89, 0x5d, 0xb9, 0x07, 0xf8,
+	0x0a, 0xbc, 0xbb, 0x75, 0x6e, 0xd4, 0x42, 0xf8, 0xa1, 0x13, 0xfb, 0xb2, 0x4a, 0xd8, 0x07, 0x77,
+	0xa8, 0xb5, 0xb8, 0xe4, 0x4d, 0xe5, 0x27, 0x5e, 0x41, 0x7b, 0xac, 0x33, 0xa5, 0x33, 0x53, 0x88,
+	0x6e, 0xe8, 0xc4, 0xe7, 0x72, 0x97, 0xcb, 0xd9, 0x5d, 0x32, 0x9f, 0x8f, 0x13, 0xf3, 0x20, 0x7a,
+	0xa1, 0x1b, 0x77, 0xe4, 0x2e, 0x97, 0xb3, 0x01, 0x25, 0xb3, 0x79, 0xb6, 0x24, 0xd1, 0xe7, 0xf3,
+	0xbb, 0x5c, 0x36, 0x20, 0xd5, 0xda, 0x64, 0xcb, 0xf4, 0x2b, 0x15, 0xe2, 0x85, 0x6d, 0x60, 0x4f,
+	0x50, 0x40, 0xcb, 0xfe, 0x7a, 0x2e, 0x90, 0xd7, 0xd6, 0x31, 0x1a, 0x42, 0xeb, 0x1b, 0xe5, 0x79,
+	0x92, 0x12, 0xbe, 0x03, 0xef, 0x81, 0x0b, 0xe6, 0x6a, 0x2f, 0x6e, 0xba, 0xd7, 0xd5, 0x43, 0xb0,
+	0xb5, 0xcb, 0x6a, 0x8a, 0x08, 0x67, 0xf7, 0x6a, 0x56, 0x70, 0xd1, 0xbe, 0xe4, 0xef, 0xe8, 0x3d,
+	0xb4, 0x59, 0x96, 0xf4, 0x13, 0xdf, 0x80, 0xbb, 0xc8, 0xd3, 0x6a, 0x49, 0xaf, 0x5e, 0x52, 0x5d,
+	0x91, 0xe5, 0xec, 0x40, 0xcf, 0x6b, 0xbd, 0xf9, 0xbc, 0x7e, 0xf3, 0x09, 0xda, 0xb7, 0xd3, 0x29,
+	0xad, 0x8c, 0xd2, 0xf8, 0x01, 0x5a, 0xda, 0xfe, 0x18, 0xf6, 0x6b, 0xb9, 0x3e, 0x7d, 0x75, 0x4a,
+	0xf2, 0xa8, 0xf1, 0x59, 0xfc, 0xde, 0x04, 0xce, 0xd3, 0x26, 0x70, 0xfe, 0x6d, 0x02, 0xe7, 0xd7,
+	0x36, 0x68, 0x3c, 0x6d, 0x83, 0xc6, 0xdf, 0x6d, 0xd0, 0xb8, 0xf7, 0xf8, 0x85, 0x7f, 0xfc, 0x3f,
+	0x00, 0x67, 0x6d, 0x94, 0x9a, 0xf1, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -417,6 +470,54 @@ func (m *Header) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Targets) > 0 {
+		for iNdEx := len(m.Targets) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Targets[iNdEx])
+			copy(dAtA[i:], m.Targets[iNdEx])
+			i = encodeVarintRouter(dAtA, i, uint64(len(m.Targets[iNdEx])))
+			i--
+			dAtA[i] = 0x1
+			i--
+			dAtA[i] = 0x92
+		}
+	}
+	if len(m.RoutingKey) > 0 {
+		i -= len(m.RoutingKey)
+		copy(dAtA[i:], m.RoutingKey)
+		i = encodeVarintRouter(dAtA, i, uint64(len(m.RoutingKey)))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x8a
+	}
+	if m.Deadline != 0 {
+		i = encodeVarintRouter(dAtA, i, uint64(m.Deadline))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x80
+	}
+	if len(m.CallPath) > 0 {
+		for iNdEx := len(m.CallPath) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.CallPath[iNdEx])
+			copy(dAtA[i:], m.CallPath[iNdEx])
+			i = encodeVarintRouter(dAtA, i, uint64(len(m.CallPath[iNdEx])))
+			i--
+			dAtA[i] = 0x7a
+		}
+	}
+	if m.Priority != 0 {
+		i = encodeVarintRouter(dAtA, i, uint64(m.Priority))
+		i--
+		dAtA[i] = 0x70
+	}
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintRouter(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x6a
+	}
 	if len(m.Custom) > 0 {
 		i -= len(m.Custom)
 		copy(dAtA[i:], m.Custom)
@@ -656,6 +757,32 @@ func (m *Header) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovRouter(uint64(l))
 	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovRouter(uint64(l))
+	}
+	if m.Priority != 0 {
+		n += 1 + sovRouter(uint64(m.Priority))
+	}
+	if len(m.CallPath) > 0 {
+		for _, s := range m.CallPath {
+			l = len(s)
+			n += 1 + l + sovRouter(uint64(l))
+		}
+	}
+	if m.Deadline != 0 {
+		n += 2 + sovRouter(uint64(m.Deadline))
+	}
+	l = len(m.RoutingKey)
+	if l > 0 {
+		n += 2 + l + sovRouter(uint64(l))
+	}
+	if len(m.Targets) > 0 {
+		for _, s := range m.Targets {
+			l = len(s)
+			n += 2 + l + sovRouter(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -1046,6 +1173,172 @@ func (m *Header) Unmarshal(dAtA []byte) error {
 				m.Custom = []byte{}
 			}
 			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Err = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CallPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CallPath = append(m.CallPath, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Deadline", wireType)
+			}
+			m.Deadline = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Deadline |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RoutingKey", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RoutingKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRouter
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRouter
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRouter
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Targets = append(m.Targets, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRouter(dAtA[iNdEx:])
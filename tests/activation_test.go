@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var activatedSends int32
+
+type mockActivatedActor struct {
+	*actor.Runtime
+	counter int
+}
+
+func newMockActivatedActor(p core.IActorBuilder) core.IActor {
+	return &mockActivatedActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (aa *mockActivatedActor) Init(ctx context.Context) {
+	aa.Runtime.Init(ctx)
+
+	aa.OnEvent("inc", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				aa.counter++
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "counter", Value: aa.counter})
+				return nil
+			},
+		}
+	})
+
+	aa.OnEvent("notify", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				atomic.AddInt32(&activatedSends, 1)
+				return nil
+			},
+		}
+	})
+}
+
+func TestActivation(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockActivatedActor"] = &core.ActorConstructor{
+		ID:               "MockActivatedActor",
+		Name:             "MockActivatedActor",
+		Weight:           20,
+		Constructor:      newMockActivatedActor,
+		Dynamic:          true,
+		ActivateOnDemand: true,
+		Options:          make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	p1, _ := getFreePort()
+	p2, _ := getFreePort()
+
+	nod1 := node.BuildProcessWithOption(
+		core.NodeWithID("test-activation-1"),
+		core.NodeWithPort(p1),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod2 := node.BuildProcessWithOption(
+		core.NodeWithID("test-activation-2"),
+		core.NodeWithPort(p2),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+
+	nod1.Init()
+	nod2.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod1.System().Exit(&wg)
+		nod2.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	time.Sleep(time.Millisecond * 500)
+
+	t.Run("call", func(t *testing.T) {
+		var mu sync.Mutex
+		var counters []int
+
+		wg := sync.WaitGroup{}
+		for _, nod := range []core.INode{nod1, nod2} {
+			wg.Add(1)
+			go func(nod core.INode) {
+				defer wg.Done()
+
+				m := msg.NewBuilder(context.TODO()).Build()
+				err := nod.System().Call("activated-call", "MockActivatedActor", "inc", m)
+				assert.Nil(t, err)
+
+				mu.Lock()
+				counters = append(counters, msg.GetResCustomField[int](m, "counter"))
+				mu.Unlock()
+			}(nod)
+		}
+		wg.Wait()
+
+		// both calls reached the same, single activation
+		sort.Ints(counters)
+		assert.Equal(t, []int{1, 2}, counters)
+
+		infos, err := nod1.System().AddressBook().GetByType(context.TODO(), "MockActivatedActor")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(infos))
+	})
+
+	t.Run("send", func(t *testing.T) {
+		err := nod2.System().Send("activated-send", "MockActivatedActor", "notify", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+
+		time.Sleep(time.Second * 2)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&activatedSends))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		// types without on-demand activation still report unknown actors
+		err := nod1.System().Call("activated-unknown", "mocka", "print", msg.NewBuilder(context.TODO()).Build())
+		assert.ErrorIs(t, err, core.ErrUnknownActor)
+	})
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressBookCache(t *testing.T) {
+	ctx := context.TODO()
+
+	transport := addressbook.NewRedisCacheTransport()
+	ab1 := addressbook.NewCache(addressbook.New(core.AddressInfo{Node: "cache-node-1", Ip: "127.0.0.1", Port: 2001}), transport, 16, time.Second*10)
+	ab2 := addressbook.NewCache(addressbook.New(core.AddressInfo{Node: "cache-node-2", Ip: "127.0.0.1", Port: 2002}), transport, 16, time.Second*10)
+	defer ab1.Clear(ctx)
+	defer ab2.Clear(ctx)
+	assert.Nil(t, ab1.KeepAlive(ctx, time.Minute))
+	assert.Nil(t, ab2.KeepAlive(ctx, time.Minute))
+
+	assert.Nil(t, ab2.Register(ctx, "cachety", "cache-a1", 10))
+	assert.Eventually(t, func() bool { return ab1.Stats().Invalidations > 0 }, time.Second, time.Millisecond*10)
+
+	// the second lookups are served by the cache
+	for i := 0; i < 2; i++ {
+		info, err := ab1.GetByID(ctx, "cache-a1")
+		assert.Nil(t, err)
+		assert.Equal(t, "cache-node-2", info.Node)
+
+		addrs, err := ab1.GetByType(ctx, "cachety")
+		assert.Nil(t, err)
+		assert.Len(t, addrs, 1)
+	}
+	stats := ab1.Stats()
+	assert.Equal(t, uint64(1), stats.IDHits)
+	assert.Equal(t, uint64(1), stats.IDMisses)
+	assert.Equal(t, uint64(1), stats.TypeHits)
+	assert.Equal(t, 0.5, stats.HitRate())
+
+	info, err := ab1.GetWildcardActor(ctx, "cachety", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "cache-a1", info.ActorId)
+
+	// a local unregister only invalidates the type of the actor
+	assert.Nil(t, ab1.Register(ctx, "cachelocal", "cache-l1", 100))
+	_, err = ab1.GetByType(ctx, "cachety")
+	assert.Nil(t, err)
+	hits := ab1.Stats().TypeHits
+	assert.Nil(t, ab1.Unregister(ctx, "cache-l1", 100))
+	_, err = ab1.GetByType(ctx, "cachety")
+	assert.Nil(t, err)
+	assert.Equal(t, hits+1, ab1.Stats().TypeHits)
+
+	// the weights are not cached, the lowest weight follows the registers at once
+	assert.Nil(t, ab1.Register(ctx, "cachety", "cache-a0", 5))
+	assert.Eventually(t, func() bool {
+		info, err := ab2.GetWildcardActor(ctx, "cachety", nil)
+		return err == nil && info.ActorId == "cache-a0"
+	}, time.Second, time.Millisecond*10)
+	assert.Nil(t, ab1.Register(ctx, "cacheheavy", "cache-h1", 100))
+	info, err = ab2.GetWildcardActor(ctx, "cachety", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "cache-a1", info.ActorId)
+	assert.Nil(t, ab1.Unregister(ctx, "cache-h1", 100))
+	assert.Nil(t, ab1.Unregister(ctx, "cache-a0", 5))
+
+	// a register on another node invalidates the cached type
+	assert.Nil(t, ab2.Register(ctx, "cachety", "cache-a2", 10))
+	assert.Eventually(t, func() bool {
+		addrs, err := ab1.GetByType(ctx, "cachety")
+		return err == nil && len(addrs) == 2
+	}, time.Second, time.Millisecond*10)
+
+	// an unregister on another node invalidates the cached id
+	assert.Nil(t, ab2.Unregister(ctx, "cache-a1", 10))
+	assert.Eventually(t, func() bool {
+		_, err := ab1.GetByID(ctx, "cache-a1")
+		return err == core.ErrUnknownActor
+	}, time.Second, time.Millisecond*10)
+
+	// unknown actors are not cached
+	misses := ab1.Stats().IDMisses
+	_, err = ab1.GetByID(ctx, "cache-a1")
+	assert.ErrorIs(t, err, core.ErrUnknownActor)
+	assert.Equal(t, misses+1, ab1.Stats().IDMisses)
+
+	// the node of a cleared book is dropped from the caches
+	_, err = ab1.GetByID(ctx, "cache-a2")
+	assert.Nil(t, err)
+	assert.Nil(t, ab2.Clear(ctx))
+	assert.Eventually(t, func() bool {
+		_, err := ab1.GetByID(ctx, "cache-a2")
+		return err == core.ErrUnknownActor
+	}, time.Second, time.Millisecond*10)
+	assert.Greater(t, ab1.Stats().Invalidations, uint64(0))
+}
+
+func TestAddressBookCacheExpire(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	// the memory books broadcast no event the other caches see, the entries only expire
+	ab1 := addressbook.NewCache(addressbook.NewMemory(core.AddressInfo{Node: "cache-mem-1"}, store), nil, 2, time.Millisecond*100)
+	ab2 := addressbook.NewMemory(core.AddressInfo{Node: "cache-mem-2"}, store)
+	defer ab1.Clear(ctx)
+
+	assert.Nil(t, ab2.Register(ctx, "cachety", "cache-mem-a1", 10))
+	_, err := ab1.GetByID(ctx, "cache-mem-a1")
+	assert.Nil(t, err)
+
+	assert.Nil(t, ab2.Unregister(ctx, "cache-mem-a1", 10))
+	_, err = ab1.GetByID(ctx, "cache-mem-a1")
+	assert.Nil(t, err) // stale
+
+	time.Sleep(time.Millisecond * 150)
+	_, err = ab1.GetByID(ctx, "cache-mem-a1")
+	assert.ErrorIs(t, err, core.ErrUnknownActor)
+
+	// the least recently used entries are evicted
+	for _, id := range []string{"cache-mem-b1", "cache-mem-b2", "cache-mem-b3"} {
+		assert.Nil(t, ab2.Register(ctx, "cachety", id, 10))
+		_, err = ab1.GetByID(ctx, id)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, uint64(1), ab1.Stats().Evictions)
+
+	// an invalidated entry is fetched again
+	hits := ab1.Stats().IDHits
+	ab1.Invalidate("cache-mem-b3")
+	_, err = ab1.GetByID(ctx, "cache-mem-b3")
+	assert.Nil(t, err)
+	assert.Equal(t, hits, ab1.Stats().IDHits)
+}
+
+func TestAddressBookCacheMemoryTransport(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	build := func(id string) *addressbook.Cache {
+		ab := addressbook.NewMemory(core.AddressInfo{Node: id}, store)
+		return addressbook.NewCache(ab, addressbook.DefaultCacheTransport(ab), 16, time.Minute)
+	}
+	ab1, ab2 := build("cache-mem-t1"), build("cache-mem-t2")
+	defer ab1.Clear(ctx)
+	defer ab2.Clear(ctx)
+
+	assert.Nil(t, ab2.Register(ctx, "cachety", "cache-mem-t-a1", 10))
+	_, err := ab1.GetByID(ctx, "cache-mem-t-a1")
+	assert.Nil(t, err)
+
+	// the caches of the memory books share the invalidations through their store
+	assert.Nil(t, ab2.Unregister(ctx, "cache-mem-t-a1", 10))
+	assert.Eventually(t, func() bool {
+		_, err := ab1.GetByID(ctx, "cache-mem-t-a1")
+		return err == core.ErrUnknownActor
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestAddressBookCacheNodes(t *testing.T) {
+	ctx := context.TODO()
+
+	build := func(id string) core.INode {
+		factory := mock.BuildActorFactory()
+		factory.Constructors["MockHashActor"] = &core.ActorConstructor{
+			ID:          "MockHashActor",
+			Name:        "MockHashActor",
+			Weight:      20,
+			Constructor: newMockHashActor,
+			Dynamic:     true,
+			Options:     make(map[string]string),
+		}
+		port, _ := getFreePort()
+
+		nod := node.BuildProcessWithOption(
+			core.NodeWithID(id),
+			core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+			core.NodeWithFactory(factory),
+			core.NodeWithPort(port),
+			core.NodeWithAddressBookCache(0, 0),
+		)
+		assert.Nil(t, nod.Init())
+		return nod
+	}
+
+	nod1, nod2 := build("cache-node-sys-1"), build("cache-node-sys-2")
+	defer func() {
+		for _, nod := range []core.INode{nod1, nod2} {
+			wg := sync.WaitGroup{}
+			nod.System().Exit(&wg)
+			wg.Wait()
+		}
+	}()
+
+	cache, ok := nod1.System().AddressBook().(core.IAddressBookCache)
+	assert.True(t, ok)
+
+	invalidations := cache.Stats().Invalidations
+	_, err := nod2.System().Loader("MockHashActor").WithID("cache-remote").Register(ctx)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return cache.Stats().Invalidations > invalidations }, time.Second, time.Millisecond*10)
+
+	for i := 0; i < 3; i++ {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, nod1.System().Call("cache-remote", "MockHashActor", "whoami", mw))
+		assert.Equal(t, "cache-remote", msg.GetResCustomField[string](mw, "id"))
+	}
+
+	assert.Equal(t, uint64(2), cache.Stats().IDHits)
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAddressBook(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	ab1 := addressbook.NewMemory(core.AddressInfo{Node: "mem-node-1", Ip: "127.0.0.1", Port: 1001}, store)
+	ab2 := addressbook.NewMemory(core.AddressInfo{Node: "mem-node-2", Ip: "127.0.0.1", Port: 1002}, store)
+
+	assert.Nil(t, ab1.Register(ctx, "mocka", "mem-a1", 10))
+	assert.Nil(t, ab1.Register(ctx, "mocka", "mem-a2", 10))
+	assert.Nil(t, ab2.Register(ctx, "mocka", "mem-a3", 5))
+	assert.NotNil(t, ab1.Register(ctx, "mocka", "mem-a1", 10))
+
+	// the books sharing a store see each other
+	info, err := ab1.GetByID(ctx, "mem-a3")
+	assert.Nil(t, err)
+	assert.Equal(t, "mem-node-2", info.Node)
+	assert.Equal(t, 1002, info.Port)
+
+	_, err = ab1.GetByID(ctx, "mem-unknown")
+	assert.ErrorIs(t, err, core.ErrUnknownActor)
+
+	cnt, err := ab2.GetActorTypeCount(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	addrs, err := ab2.GetByType(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Len(t, addrs, 3)
+
+	// node 2 carries the lower weight
+	for i := 0; i < 10; i++ {
+		info, err = ab1.GetWildcardActor(ctx, "mocka", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "mem-a3", info.ActorId)
+	}
+	info, err = ab1.GetLowWeightNodeForActor(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Equal(t, "mem-node-2", info.Node)
+
+	assert.Nil(t, ab1.JoinGroup(ctx, "mem-group", "mem-a1"))
+	assert.Nil(t, ab2.JoinGroup(ctx, "mem-group", "mem-a3"))
+	assert.ErrorIs(t, ab1.JoinGroup(ctx, "mem-group", "mem-unknown"), core.ErrUnknownActor)
+	members, err := ab1.GetGroup(ctx, "mem-group")
+	assert.Nil(t, err)
+	assert.Len(t, members, 2)
+
+	assert.Nil(t, ab2.Unregister(ctx, "mem-a3", 5))
+	assert.NotNil(t, ab2.Unregister(ctx, "mem-a3", 5))
+	members, _ = ab1.GetGroup(ctx, "mem-group")
+	assert.Len(t, members, 1)
+
+	// clear removes the actors of its own node only
+	assert.Nil(t, ab2.Register(ctx, "mockb", "mem-b1", 5))
+	assert.Nil(t, ab1.Clear(ctx))
+	cnt, _ = ab1.GetActorTypeCount(ctx, "mocka")
+	assert.Equal(t, int64(0), cnt)
+	members, _ = ab1.GetGroup(ctx, "mem-group")
+	assert.Empty(t, members)
+	_, err = ab1.GetByID(ctx, "mem-b1")
+	assert.Nil(t, err)
+}
+
+func TestMemoryAddressBookNodes(t *testing.T) {
+	store := addressbook.NewMemoryStore()
+	ctx := context.TODO()
+
+	build := func(id string) core.INode {
+		factory := mock.BuildActorFactory()
+		factory.Constructors["MockCallOptsActor"] = &core.ActorConstructor{
+			ID:          "MockCallOptsActor",
+			Name:        "MockCallOptsActor",
+			Weight:      20,
+			Constructor: newMockCallOptsActor,
+			Dynamic:     true,
+			Options:     make(map[string]string),
+		}
+		port, _ := getFreePort()
+
+		nod := node.BuildProcessWithOption(
+			core.NodeWithID(id),
+			core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+			core.NodeWithFactory(factory),
+			core.NodeWithPort(port),
+			core.NodeWithAddressBook(addressbook.Memory(store)),
+		)
+		assert.Nil(t, nod.Init())
+		return nod
+	}
+
+	nod1, nod2 := build("mem-node-1"), build("mem-node-2")
+	defer func() {
+		for _, nod := range []core.INode{nod1, nod2} {
+			wg := sync.WaitGroup{}
+			nod.System().Exit(&wg)
+			wg.Wait()
+		}
+	}()
+
+	_, err := nod2.System().Loader("MockCallOptsActor").WithID("mem-remote").Register(ctx)
+	assert.Nil(t, err)
+
+	// node 1 finds the actor of node 2 in the shared memory store, the call goes through grpc
+	mw := msg.NewBuilder(ctx).Build()
+	assert.Nil(t, nod1.System().Call("mem-remote", "MockCallOptsActor", "priority", mw, core.CallWithPriority(msg.PriorityHigh)))
+	assert.Equal(t, int(msg.PriorityHigh), msg.GetResCustomField[int](mw, "priority"))
+}
+
+func TestMemoryAddressBookWithoutRedis(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockActivatedActor"] = &core.ActorConstructor{
+		ID:               "MockActivatedActor",
+		Name:             "MockActivatedActor",
+		Weight:           20,
+		Constructor:      newMockActivatedActor,
+		Dynamic:          true,
+		ActivateOnDemand: true,
+		Options:          make(map[string]string),
+	}
+
+	build := func(id string) core.INode {
+		port, _ := getFreePort()
+		nod := node.BuildProcessWithOption(
+			core.NodeWithID(id),
+			core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+			core.NodeWithFactory(factory),
+			core.NodeWithPort(port),
+			core.NodeWithAddressBook(addressbook.Memory(store)),
+		)
+		assert.Nil(t, nod.Init())
+		return nod
+	}
+
+	nod1, nod2 := build("mem-noredis-1"), build("mem-noredis-2")
+	defer func() {
+		for _, nod := range []core.INode{nod1, nod2} {
+			wg := sync.WaitGroup{}
+			nod.System().Exit(&wg)
+			wg.Wait()
+		}
+	}()
+
+	// the activation lock is held in the process, both calls reach a single activation
+	var mu sync.Mutex
+	var counters []int
+	wg := sync.WaitGroup{}
+	for _, nod := range []core.INode{nod1, nod2} {
+		wg.Add(1)
+		go func(nod core.INode) {
+			defer wg.Done()
+			mw := msg.NewBuilder(ctx).Build()
+			assert.Nil(t, nod.System().Call("mem-activated", "MockActivatedActor", "inc", mw))
+			mu.Lock()
+			counters = append(counters, msg.GetResCustomField[int](mw, "counter"))
+			mu.Unlock()
+		}(nod)
+	}
+	wg.Wait()
+	sort.Ints(counters)
+	assert.Equal(t, []int{1, 2}, counters)
+
+	// reminders and watches are kept in redis
+	sys := nod1.System()
+	err := sys.RegisterReminder(ctx, core.Reminder{ActorID: "mem-activated", ActorTy: "MockActivatedActor", Name: "r", Event: "inc"})
+	assert.ErrorIs(t, err, core.ErrRedisRequired)
+	_, err = sys.Reminders(ctx, "mem-activated")
+	assert.ErrorIs(t, err, core.ErrRedisRequired)
+	assert.ErrorIs(t, sys.Watch(ctx, "mem-watcher", "MockActivatedActor", "mem-activated"), core.ErrRedisRequired)
+
+	info, err := sys.AddressBook().GetByID(ctx, "mem-activated")
+	assert.Nil(t, err)
+	owner := nod1
+	if info.Node == nod2.ID() {
+		owner = nod2
+	}
+	assert.Nil(t, owner.System().Unregister("mem-activated", "MockActivatedActor"))
+}
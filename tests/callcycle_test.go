@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCycleActor struct {
+	*actor.Runtime
+	hold      chan struct{}
+	forwarded chan struct{}
+}
+
+func newMockCycleActor(p core.IActorBuilder) core.IActor {
+	return &mockCycleActor{
+		Runtime:   &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+		hold:      make(chan struct{}),
+		forwarded: make(chan struct{}, 1),
+	}
+}
+
+func (ca *mockCycleActor) Init(ctx context.Context) {
+	ca.Runtime.Init(ctx)
+
+	// relay calls the next hop ("id:type[:event],id:type ..."), the error of the call chain is returned in the response
+	ca.OnEvent("relay", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				hops := msg.GetReqCustomField[string](mw, "hops")
+				if hops == "" {
+					mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "err", Value: ""})
+					return nil
+				}
+
+				next, rest, _ := strings.Cut(hops, ",")
+				id, ty, _ := strings.Cut(next, ":")
+				ty, event, _ := strings.Cut(ty, ":")
+				if event == "" {
+					event = "relay"
+				}
+
+				// a fresh context, a message sharing the wait group of mw is not waited on by the call
+				nmw := msg.NewBuilder(context.TODO()).WithReqCustomFields(msg.Attr{Key: "hops", Value: rest}).Build()
+				errmsg := ""
+				if err := ctx.Call(id, ty, event, nmw); err != nil {
+					errmsg = err.Error()
+				} else {
+					errmsg = msg.GetResCustomField[string](nmw, "err")
+				}
+
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "err", Value: errmsg})
+				return nil
+			},
+		}
+	})
+
+	// forward sends the message back to its caller, which is still waiting for the call
+	ca.OnEvent("forward", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Send(mw.Req.Header.OrgActorID, mw.Req.Header.OrgActorType, "forwarded", mw)
+			},
+		}
+	})
+
+	ca.OnEvent("forwarded", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				ca.forwarded <- struct{}{}
+				return nil
+			},
+		}
+	})
+
+	ca.OnEvent("hold", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				<-ca.hold
+				return nil
+			},
+		}
+	})
+}
+
+func TestCallCycle(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	for _, ty := range []string{"MockCycleA", "MockCycleB"} {
+		factory.Constructors[ty] = &core.ActorConstructor{
+			ID:          ty,
+			Name:        ty,
+			Weight:      20,
+			Constructor: newMockCycleActor,
+			Dynamic:     true,
+			Options:     make(map[string]string),
+		}
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-callcycle-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+		core.NodeWithCallGraph(),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	_, err := sys.Loader("MockCycleA").WithID("cycle-a").Register(ctx)
+	assert.Nil(t, err)
+	_, err = sys.Loader("MockCycleB").WithID("cycle-b").Register(ctx)
+	assert.Nil(t, err)
+	c, err := sys.Loader("MockCycleB").WithID("cycle-c").Register(ctx)
+	assert.Nil(t, err)
+
+	relay := func(t *testing.T, first, ty, hops string) string {
+		mw := msg.NewBuilder(ctx).WithReqCustomFields(msg.Attr{Key: "hops", Value: hops}).Build()
+
+		begin := time.Now()
+		assert.Nil(t, sys.Call(first, ty, "relay", mw))
+		assert.Less(t, time.Since(begin), time.Second) // failed fast, not after the call timeout
+
+		return msg.GetResCustomField[string](mw, "err")
+	}
+
+	t.Run("no cycle", func(t *testing.T) {
+		assert.Equal(t, "", relay(t, "cycle-a", "MockCycleA", "cycle-b:MockCycleB,cycle-c:MockCycleB"))
+	})
+
+	t.Run("named cycle", func(t *testing.T) {
+		errmsg := relay(t, "cycle-a", "MockCycleA", "cycle-b:MockCycleB,cycle-c:MockCycleB,cycle-b:MockCycleB")
+		assert.Contains(t, errmsg, core.ErrCallCycle.Error())
+		assert.Contains(t, errmsg, "cycle-b -> cycle-c -> cycle-b")
+	})
+
+	t.Run("cycle through a symbol", func(t *testing.T) {
+		// the target is only known once the symbol is resolved, the cycle is detected when it is received
+		errmsg := relay(t, "cycle-a", "MockCycleA", "cycle-b:MockCycleB,"+def.SymbolLocalFirst+":MockCycleA")
+		assert.Contains(t, errmsg, "cycle-a -> cycle-b -> cycle-a")
+	})
+
+	t.Run("send to a caller", func(t *testing.T) {
+		// cycle-b sends its message back to cycle-a, a send waits on no one and is not a cycle
+		assert.Equal(t, "", relay(t, "cycle-a", "MockCycleA", "cycle-b:MockCycleB:forward"))
+
+		a, err := sys.FindActor(ctx, "cycle-a")
+		assert.Nil(t, err)
+		select {
+		case <-a.(*mockCycleActor).forwarded:
+		case <-time.After(time.Second):
+			t.Fatal("the message sent to the caller was not delivered")
+		}
+	})
+
+	t.Run("wait-for graph", func(t *testing.T) {
+		// cycle-a waits on cycle-c, which is busy with a held message
+		assert.Nil(t, sys.Send("cycle-c", "MockCycleB", "hold", msg.NewBuilder(ctx).Build()))
+
+		done := make(chan struct{})
+		go func() {
+			mw := msg.NewBuilder(ctx).WithReqCustomFields(msg.Attr{Key: "hops", Value: "cycle-c:MockCycleB"}).Build()
+			sys.Call("cycle-a", "MockCycleA", "relay", mw)
+			close(done)
+		}()
+		time.Sleep(time.Millisecond * 100)
+
+		edges := sys.WaitForGraph()
+		assert.Len(t, edges, 1)
+		if len(edges) == 1 {
+			assert.Equal(t, "cycle-a", edges[0].Caller)
+			assert.Equal(t, "cycle-c", edges[0].Callee)
+		}
+		assert.Contains(t, core.DumpWaitForGraph(edges), "cycle-a -> cycle-c event relay")
+
+		close(c.(*mockCycleActor).hold)
+		<-done
+		assert.Empty(t, sys.WaitForGraph())
+	})
+
+	assert.True(t, errors.Is(core.CallCycle([]string{"a", "b"}, "a"), core.ErrCallCycle))
+}
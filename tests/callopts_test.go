@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCallOptsActor struct {
+	*actor.Runtime
+	hold    chan struct{}
+	flakies int32
+}
+
+func newMockCallOptsActor(p core.IActorBuilder) core.IActor {
+	return &mockCallOptsActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+		hold:    make(chan struct{}),
+	}
+}
+
+func (ca *mockCallOptsActor) Init(ctx context.Context) {
+	ca.Runtime.Init(ctx)
+
+	ca.OnEvent("slow", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				time.Sleep(time.Millisecond * 300)
+				return nil
+			},
+		}
+	})
+
+	// flaky is slow on its first call only
+	ca.OnEvent("flaky", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				if atomic.AddInt32(&ca.flakies, 1) == 1 {
+					time.Sleep(time.Millisecond * 150)
+				}
+				return nil
+			},
+		}
+	})
+
+	ca.OnEvent("priority", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "priority", Value: int(mw.Req.Header.Priority)})
+				return nil
+			},
+		}
+	})
+
+	ca.OnEvent("hold", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				<-ca.hold
+				return nil
+			},
+		}
+	})
+
+	ca.OnEvent("work", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return nil
+			},
+		}
+	})
+}
+
+func TestCallOptions(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockCallOptsActor"] = &core.ActorConstructor{
+		ID:          "MockCallOptsActor",
+		Name:        "MockCallOptsActor",
+		Weight:      20,
+		Constructor: newMockCallOptsActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	var mu sync.Mutex
+	var reasons []core.DeadLetterReason
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-callopts-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+		core.NodeWithDeadLetterSink(core.DeadLetterFunc(func(dl core.DeadLetter) {
+			mu.Lock()
+			reasons = append(reasons, dl.Reason)
+			mu.Unlock()
+		})),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := sys.Loader("MockCallOptsActor").WithID("callopts-1").Register(ctx)
+		assert.Nil(t, err)
+
+		mw := msg.NewBuilder(ctx).Build()
+		begin := time.Now()
+		sys.Call("callopts-1", "MockCallOptsActor", "slow", mw, core.CallWithTimeout(time.Millisecond*50))
+		assert.Less(t, time.Since(begin), time.Millisecond*250)
+		assert.NotNil(t, mw.Err)
+
+		// the attempt runs on its own copy, the context and deadline of the call do not leak into mw
+		assert.Equal(t, ctx, mw.Ctx)
+		assert.Equal(t, int64(0), mw.Req.Header.Deadline)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		a, err := sys.Loader("MockCallOptsActor").WithID("callopts-2").Register(ctx)
+		assert.Nil(t, err)
+
+		// the first attempt times out, the second one is handled once the actor is free again
+		mw := msg.NewBuilder(ctx).Build()
+		err = sys.Call("callopts-2", "MockCallOptsActor", "flaky", mw,
+			core.CallWithTimeout(time.Millisecond*100),
+			core.CallWithRetry(2, time.Millisecond*10),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, mw.Err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&a.(*mockCallOptsActor).flakies))
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		_, err := sys.Loader("MockCallOptsActor").WithID("callopts-3").Register(ctx)
+		assert.Nil(t, err)
+
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, sys.Call("callopts-3", "MockCallOptsActor", "priority", mw, core.CallWithPriority(msg.PriorityHigh)))
+		assert.Equal(t, int(msg.PriorityHigh), msg.GetResCustomField[int](mw, "priority"))
+	})
+
+	t.Run("expired send is skipped", func(t *testing.T) {
+		a, err := sys.Loader("MockCallOptsActor").WithID("callopts-4").Register(ctx)
+		assert.Nil(t, err)
+
+		assert.Nil(t, sys.Send("callopts-4", "MockCallOptsActor", "hold", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Send("callopts-4", "MockCallOptsActor", "work", msg.NewBuilder(ctx).Build(),
+			core.CallWithTimeout(time.Millisecond*50)))
+
+		// the deadline passes while the actor is busy
+		time.Sleep(time.Millisecond * 100)
+		close(a.(*mockCallOptsActor).hold)
+		time.Sleep(time.Millisecond * 100)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Contains(t, reasons, core.DeadLetterExpired)
+	})
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var cronSkipped int32
+var cronOnce int32
+
+type mockCronActor struct {
+	*actor.Runtime
+}
+
+func newMockCronActor(p core.IActorBuilder) core.IActor {
+	return &mockCronActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ca *mockCronActor) Init(ctx context.Context) {
+	ca.Runtime.Init(ctx)
+
+	ca.OnCron("* * * * * *", time.UTC, func(i interface{}) error {
+		atomic.AddInt32(&cronSkipped, 1)
+		return nil
+	}, nil, core.CronWithMisfire(core.CronMisfireSkip), core.CronWithMisfireThreshold(time.Millisecond*500))
+
+	ca.OnCron("* * * * * *", time.UTC, func(i interface{}) error {
+		atomic.AddInt32(&cronOnce, 1)
+		return nil
+	}, nil, core.CronWithMisfire(core.CronMisfireFireOnce))
+
+	ca.OnEvent("block", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				time.Sleep(time.Millisecond * 2500)
+				return nil
+			},
+		}
+	})
+}
+
+func TestActorCron(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockCronActor"] = &core.ActorConstructor{
+		ID:          "MockCronActor",
+		Name:        "MockCronActor",
+		Weight:      20,
+		Constructor: newMockCronActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-cron-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	a, err := nod.System().Loader("MockCronActor").WithID("cron-1").Register(context.TODO())
+	assert.Nil(t, err)
+
+	_, err = a.OnCron("* * *", nil, func(i interface{}) error { return nil }, nil)
+	assert.NotNil(t, err)
+
+	t.Run("tick", func(t *testing.T) {
+		time.Sleep(time.Millisecond * 3100)
+		cnt := atomic.LoadInt32(&cronOnce)
+		assert.True(t, cnt >= 3 && cnt <= 4, cnt)
+	})
+
+	t.Run("misfire", func(t *testing.T) {
+		// start blocking 200ms after a fire, so that the next fire is queued behind the blocking message
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second + time.Millisecond*200)))
+
+		skipped, once := atomic.LoadInt32(&cronSkipped), atomic.LoadInt32(&cronOnce)
+
+		err := nod.System().Call("cron-1", "MockCronActor", "block", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond * 100)
+
+		// the missed fires are dropped by the skip policy, and run once by the fire once policy
+		assert.Equal(t, skipped, atomic.LoadInt32(&cronSkipped))
+		assert.Equal(t, once+1, atomic.LoadInt32(&cronOnce))
+	})
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var flakyCalls int32
+var sunkLetters int32
+
+type mockDeadLetterActor struct {
+	*actor.Runtime
+}
+
+func newMockDeadLetterActor(p core.IActorBuilder) core.IActor {
+	return &mockDeadLetterActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (da *mockDeadLetterActor) Init(ctx context.Context) {
+	da.Runtime.Init(ctx)
+
+	// fails the first time it is called
+	da.OnEvent("flaky", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				if atomic.AddInt32(&flakyCalls, 1) == 1 {
+					return errors.New("flaky failure")
+				}
+				return nil
+			},
+		}
+	})
+
+	da.OnEvent("hold", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				time.Sleep(time.Millisecond * 200)
+				return nil
+			},
+		}
+	})
+
+	da.OnEvent(core.DeadLetterEvent, func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				if _, err := core.DecodeDeadLetter(mw.Req.Body); err != nil {
+					return err
+				}
+				atomic.AddInt32(&sunkLetters, 1)
+				return nil
+			},
+		}
+	})
+}
+
+func TestDeadLetter(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockDeadLetterActor"] = &core.ActorConstructor{
+		ID:          "MockDeadLetterActor",
+		Name:        "MockDeadLetterActor",
+		Weight:      20,
+		Constructor: newMockDeadLetterActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	buffer := core.NewDeadLetterBuffer(16)
+	var callbacks int32
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-deadletter-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+		core.NodeWithDeadLetterSink(buffer),
+		core.NodeWithDeadLetterSink(core.DeadLetterFunc(func(dl core.DeadLetter) {
+			atomic.AddInt32(&callbacks, 1)
+		})),
+		core.NodeWithDeadLetterSink(&core.DeadLetterActorSink{ActorID: "deadletter-sink", ActorTy: "MockDeadLetterActor"}),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	_, err := sys.Loader("MockDeadLetterActor").WithID("deadletter-1").Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = sys.Loader("MockDeadLetterActor").WithID("deadletter-sink").Register(context.TODO())
+	assert.Nil(t, err)
+	overflow, err := sys.Loader("MockDeadLetterActor").WithID("deadletter-overflow").
+		WithMailbox(1, core.MailboxReject).Register(context.TODO())
+	assert.Nil(t, err)
+	closed, err := sys.Loader("MockDeadLetterActor").WithID("deadletter-closed").Register(context.TODO())
+	assert.Nil(t, err)
+
+	t.Run("unknown event", func(t *testing.T) {
+		err := sys.Call("deadletter-1", "MockDeadLetterActor", "unknown", msg.NewBuilder(context.TODO()).WithReqBody([]byte("body")).Build())
+		assert.Nil(t, err)
+
+		letters := buffer.List(core.DeadLetterWithReason(core.DeadLetterUnknownEvent))
+		assert.Equal(t, 1, len(letters))
+		assert.Equal(t, "unknown", letters[0].Header.Event)
+		assert.Equal(t, "deadletter-1", letters[0].Header.TargetActorID)
+		assert.Equal(t, []byte("body"), letters[0].Body)
+		assert.Equal(t, "test-deadletter-1", letters[0].Node)
+	})
+
+	t.Run("mailbox overflow", func(t *testing.T) {
+		assert.Nil(t, sys.Send("deadletter-overflow", "MockDeadLetterActor", "hold", msg.NewBuilder(context.TODO()).Build()))
+		time.Sleep(time.Millisecond * 50)
+		assert.Nil(t, sys.Send("deadletter-overflow", "MockDeadLetterActor", "hold", msg.NewBuilder(context.TODO()).Build()))
+		err := sys.Send("deadletter-overflow", "MockDeadLetterActor", "hold", msg.NewBuilder(context.TODO()).Build())
+		assert.True(t, errors.Is(err, core.ErrMailboxFull))
+
+		assert.Equal(t, 1, len(buffer.List(core.DeadLetterWithReason(core.DeadLetterMailboxOverflow))))
+		assert.Equal(t, uint64(1), overflow.(*mockDeadLetterActor).MailboxDropped())
+	})
+
+	t.Run("actor closed", func(t *testing.T) {
+		assert.Nil(t, sys.Unregister("deadletter-closed", "MockDeadLetterActor"))
+		time.Sleep(time.Millisecond * 100)
+
+		err := closed.Received(msg.NewBuilder(context.TODO()).WithReqHeader(&router.Header{Event: "flaky", TargetActorID: "deadletter-closed"}).Build())
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, len(buffer.List(core.DeadLetterWithReason(core.DeadLetterActorClosed))))
+	})
+
+	t.Run("handler error and replay", func(t *testing.T) {
+		atomic.StoreInt32(&flakyCalls, 0)
+
+		err := sys.Call("deadletter-1", "MockDeadLetterActor", "flaky", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+
+		letters := buffer.List(core.DeadLetterWithReason(core.DeadLetterHandlerError))
+		assert.Equal(t, 1, len(letters))
+		assert.Equal(t, "flaky failure", letters[0].Err)
+
+		sent, err := buffer.Replay(sys, core.DeadLetterWithReason(core.DeadLetterHandlerError))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sent)
+
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&flakyCalls))
+		assert.Equal(t, 0, len(buffer.List(core.DeadLetterWithReason(core.DeadLetterHandlerError))))
+	})
+
+	t.Run("remote call failure", func(t *testing.T) {
+		// the actor is recorded on a node which does not answer
+		port, _ := getFreePort()
+		ghost := addressbook.New(core.AddressInfo{Node: "test-deadletter-ghost", Ip: "127.0.0.1", Port: port})
+		assert.Nil(t, ghost.Register(context.TODO(), "MockDeadLetterActor", "deadletter-remote", 20))
+		defer ghost.Clear(context.TODO())
+
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+		err := sys.Call("deadletter-remote", "MockDeadLetterActor", "flaky", msg.NewBuilder(ctx).Build())
+		assert.NotNil(t, err)
+
+		letters := buffer.List(core.DeadLetterWithReason(core.DeadLetterRemoteFailure))
+		assert.Equal(t, 1, len(letters))
+		assert.Equal(t, "deadletter-remote", letters[0].Header.TargetActorID)
+	})
+
+	t.Run("sinks", func(t *testing.T) {
+		time.Sleep(time.Millisecond * 100)
+
+		// every dead letter reached the callback and the dead letter actor
+		assert.Equal(t, int32(5), atomic.LoadInt32(&callbacks))
+		assert.Equal(t, int32(5), atomic.LoadInt32(&sunkLetters))
+		assert.Equal(t, 4, len(buffer.List(nil)))
+		assert.Equal(t, 1, len(buffer.List(core.DeadLetterWithActor("deadletter-1"))))
+	})
+}
+
+// blockingPubSystem holds the publications until it is released
+type blockingPubSystem struct {
+	core.ISystem
+	release chan struct{}
+	pubs    int32
+}
+
+func (s *blockingPubSystem) Pub(topic string, event string, body []byte) error {
+	<-s.release
+	atomic.AddInt32(&s.pubs, 1)
+	return nil
+}
+
+func TestDeadLetterSinkQueue(t *testing.T) {
+	sys := &blockingPubSystem{release: make(chan struct{})}
+	sink := &core.DeadLetterTopicSink{Topic: "deadletters", QueueSize: 2}
+
+	// a burst of dead letters does not start a goroutine per letter, the overflow of the queue is dropped
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		sink.Receive(sys, core.DeadLetter{Reason: core.DeadLetterRemoteFailure, Header: &router.Header{}})
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines+1)
+	assert.GreaterOrEqual(t, sink.Dropped(), uint64(7))
+
+	close(sys.release)
+	assert.Eventually(t, func() bool {
+		return uint64(atomic.LoadInt32(&sys.pubs))+sink.Dropped() == 10
+	}, time.Second, time.Millisecond*10)
+
+	// the worker stops with the system, the letters received afterwards are dropped
+	sink.Close()
+	for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	sink.Receive(sys, core.DeadLetter{Reason: core.DeadLetterRemoteFailure, Header: &router.Header{}})
+	assert.Equal(t, uint64(11), uint64(atomic.LoadInt32(&sys.pubs))+sink.Dropped())
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+)
+
+var benchDispatched int64
+
+type mockBenchDispatchActor struct {
+	*actor.Runtime
+}
+
+func newMockBenchDispatchActor(p core.IActorBuilder) core.IActor {
+	return &mockBenchDispatchActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ba *mockBenchDispatchActor) Init(ctx context.Context) {
+	ba.Runtime.Init(ctx)
+
+	ba.OnEvent("bench", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				atomic.AddInt64(&benchDispatched, 1)
+				return nil
+			},
+		}
+	})
+}
+
+// BenchmarkDispatcher compares one goroutine per actor with a shared worker pool,
+// 10000 mostly idle actors are registered and the messages are spread over all of them
+//
+//	go test -benchmem -run=^$ -bench ^BenchmarkDispatcher$ github.com/pojol/braid/tests -v
+func BenchmarkDispatcher(b *testing.B) {
+	const actors = 10000
+
+	modes := []struct {
+		name       string
+		dispatcher core.IDispatcher
+	}{
+		{"goroutine", nil},
+		{"dispatcher", actor.NewDispatcher()},
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			factory := mock.BuildActorFactory()
+			factory.Constructors["MockBenchDispatchActor"] = &core.ActorConstructor{
+				ID:          "MockBenchDispatchActor",
+				Name:        "MockBenchDispatchActor",
+				Weight:      1,
+				Constructor: newMockBenchDispatchActor,
+				Dynamic:     true,
+				Dispatcher:  mode.dispatcher,
+				Options:     make(map[string]string),
+			}
+
+			nod := node.BuildProcessWithOption(
+				core.NodeWithID("bench-dispatcher-"+mode.name),
+				core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+				core.NodeWithFactory(factory),
+			)
+			nod.Init()
+			defer func() {
+				wg := sync.WaitGroup{}
+				nod.System().Exit(&wg)
+				wg.Wait()
+			}()
+
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			goroutines := runtime.NumGoroutine()
+
+			for i := 0; i < actors; i++ {
+				nod.System().Loader("MockBenchDispatchActor").WithID("bench-dispatch-" + strconv.Itoa(i)).WithLocal().Register(context.TODO())
+			}
+
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.HeapInuse+after.StackInuse-before.HeapInuse-before.StackInuse)/actors, "B/actor")
+			b.ReportMetric(float64(runtime.NumGoroutine()-goroutines), "goroutines")
+
+			atomic.StoreInt64(&benchDispatched, 0)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				nod.System().Send("bench-dispatch-"+strconv.Itoa(i%actors), "MockBenchDispatchActor", "bench",
+					msg.NewBuilder(context.TODO()).Build())
+			}
+			for atomic.LoadInt64(&benchDispatched) < int64(b.N) {
+				time.Sleep(time.Microsecond * 100)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var dispatchOverlaps int32
+var dispatchHandled int32
+
+type mockDispatchActor struct {
+	*actor.Runtime
+	busy int32
+}
+
+func newMockDispatchActor(p core.IActorBuilder) core.IActor {
+	return &mockDispatchActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+// enter records an overlap if another handler, timer or callback of the actor is running
+func (da *mockDispatchActor) enter(d time.Duration) {
+	if !atomic.CompareAndSwapInt32(&da.busy, 0, 1) {
+		atomic.AddInt32(&dispatchOverlaps, 1)
+		return
+	}
+	time.Sleep(d)
+	atomic.StoreInt32(&da.busy, 0)
+}
+
+func (da *mockDispatchActor) Init(ctx context.Context) {
+	da.Runtime.Init(ctx)
+
+	da.OnTimer(0, 5, func(i interface{}) error {
+		da.enter(time.Microsecond * 50)
+		return nil
+	}, nil)
+
+	da.OnEvent("work", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				da.enter(time.Microsecond * 50)
+
+				// the continuation of a reentrant call runs on the actor as well
+				peer := msg.GetReqCustomField[string](mw, "peer")
+				if peer != "" {
+					ctx.ReenterCall(peer, da.Ty, "echo", msg.NewBuilder(context.TODO()).Build()).Then(func(w *msg.Wrapper) {
+						da.enter(time.Microsecond * 50)
+					})
+				}
+				atomic.AddInt32(&dispatchHandled, 1)
+				return nil
+			},
+		}
+	})
+
+	da.OnEvent("echo", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				da.enter(time.Microsecond * 50)
+				return nil
+			},
+		}
+	})
+
+	da.OnEvent("slow", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				time.Sleep(time.Millisecond)
+				return nil
+			},
+		}
+	})
+}
+
+func TestDispatcher(t *testing.T) {
+	dispatcher := actor.NewDispatcher(actor.DispatcherWithWorkers(4), actor.DispatcherWithThroughput(2))
+	single := actor.NewDispatcher(actor.DispatcherWithWorkers(1), actor.DispatcherWithThroughput(1))
+	defer dispatcher.Exit()
+	defer single.Exit()
+
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockDispatchActor"] = &core.ActorConstructor{
+		ID:          "MockDispatchActor",
+		Name:        "MockDispatchActor",
+		Weight:      20,
+		Constructor: newMockDispatchActor,
+		Dynamic:     true,
+		Dispatcher:  dispatcher,
+		Options:     make(map[string]string),
+	}
+	factory.Constructors["MockSingleDispatchActor"] = &core.ActorConstructor{
+		ID:          "MockSingleDispatchActor",
+		Name:        "MockSingleDispatchActor",
+		Weight:      20,
+		Constructor: newMockDispatchActor,
+		Dynamic:     true,
+		Dispatcher:  single,
+		Options:     make(map[string]string),
+	}
+	factory.Constructors["MockDispatchLifecycleActor"] = &core.ActorConstructor{
+		ID:          "MockDispatchLifecycleActor",
+		Name:        "MockDispatchLifecycleActor",
+		Weight:      20,
+		Constructor: newMockLifecycleActor,
+		Dynamic:     true,
+		Dispatcher:  dispatcher,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-dispatcher-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	t.Run("turns never overlap", func(t *testing.T) {
+		const actors, msgs = 8, 100
+
+		for i := 0; i < actors; i++ {
+			_, err := sys.Loader("MockDispatchActor").WithID("dispatch-" + strconv.Itoa(i)).WithLocal().Register(ctx)
+			assert.Nil(t, err)
+		}
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < actors; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < msgs; j++ {
+					mw := msg.NewBuilder(ctx).WithReqCustomFields(msg.Attr{Key: "peer", Value: "dispatch-" + strconv.Itoa((i+1)%actors)}).Build()
+					assert.Nil(t, sys.Send("dispatch-"+strconv.Itoa(i), "MockDispatchActor", "work", mw))
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&dispatchHandled) == actors*msgs
+		}, time.Second*5, time.Millisecond*10)
+		time.Sleep(time.Millisecond * 100) // the last continuations and timers
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&dispatchOverlaps))
+	})
+
+	t.Run("throughput quota", func(t *testing.T) {
+		_, err := sys.Loader("MockSingleDispatchActor").WithID("dispatch-flood").WithLocal().Register(ctx)
+		assert.Nil(t, err)
+		_, err = sys.Loader("MockSingleDispatchActor").WithID("dispatch-quick").WithLocal().Register(ctx)
+		assert.Nil(t, err)
+
+		for i := 0; i < 200; i++ {
+			assert.Nil(t, sys.Send("dispatch-flood", "MockSingleDispatchActor", "slow", msg.NewBuilder(ctx).Build()))
+		}
+
+		// a single worker is shared turn by turn, the flooded actor does not hold it until its mailbox is empty
+		begin := time.Now()
+		assert.Nil(t, sys.Call("dispatch-quick", "MockSingleDispatchActor", "echo", msg.NewBuilder(ctx).Build()))
+		assert.Less(t, time.Since(begin), time.Millisecond*100)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		_, err := sys.Loader("MockDispatchLifecycleActor").WithID("dispatch-lifecycle").
+			WithSupervisor(&core.SupervisorStrategy{Directive: core.SupervisorRestart}).Register(ctx)
+		assert.Nil(t, err)
+
+		assert.Nil(t, sys.Call("dispatch-lifecycle", "MockDispatchLifecycleActor", "ping", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Send("dispatch-lifecycle", "MockDispatchLifecycleActor", "boom", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Send("dispatch-lifecycle", "MockDispatchLifecycleActor", "ping", msg.NewBuilder(ctx).Build()))
+		time.Sleep(time.Millisecond * 200)
+		assert.Nil(t, sys.Unregister("dispatch-lifecycle", "MockDispatchLifecycleActor"))
+
+		assert.Equal(t, []string{"start", "ping", "restart boom", "ping", "stop"}, lifecycleOf("dispatch-lifecycle"))
+
+		// a failed start is rolled back
+		_, err = sys.Loader("MockDispatchLifecycleActor").WithID("lifecycle-fail").Register(ctx)
+		assert.NotNil(t, err)
+		_, err = sys.FindActor(ctx, "lifecycle-fail")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// futureResult records what a continuation observed, it is written by the joiner actor
+type futureResult struct {
+	sum      int
+	err      error
+	goid     uint64 // goroutine of the continuation
+	actorGid uint64 // goroutine of the handler which made the calls
+}
+
+var futureResults = make(chan futureResult, 1)
+
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(buf[:bytes.IndexByte(buf, ' ')]), 10, 64)
+	return id
+}
+
+type mockFutureWorker struct {
+	*actor.Runtime
+}
+
+func newMockFutureWorker(p core.IActorBuilder) core.IActor {
+	return &mockFutureWorker{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (fw *mockFutureWorker) Init(ctx context.Context) {
+	fw.Runtime.Init(ctx)
+
+	fw.OnEvent("double", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(w *msg.Wrapper) error {
+				val := msg.GetReqCustomField[int](w, "val")
+				if val == 2 {
+					time.Sleep(time.Millisecond * 300) // the slow worker
+				}
+				w.ToBuilder().WithResCustomFields(msg.Attr{Key: "val", Value: val * 2})
+				return nil
+			},
+		}
+	})
+}
+
+type mockFutureJoiner struct {
+	*actor.Runtime
+}
+
+func newMockFutureJoiner(p core.IActorBuilder) core.IActor {
+	return &mockFutureJoiner{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (fj *mockFutureJoiner) fanOut(ctx core.ActorContext, vals ...int) ([]*msg.Wrapper, []core.IFuture) {
+	wrappers := make([]*msg.Wrapper, len(vals))
+	futures := make([]core.IFuture, len(vals))
+	for i, val := range vals {
+		wrappers[i] = msg.NewBuilder(context.TODO()).WithReqCustomFields(msg.Attr{Key: "val", Value: val}).Build()
+		futures[i] = ctx.ReenterCall("future-worker-"+strconv.Itoa(val), "MockFutureWorker", "double", wrappers[i])
+	}
+	return wrappers, futures
+}
+
+func (fj *mockFutureJoiner) Init(ctx context.Context) {
+	fj.Runtime.Init(ctx)
+
+	fj.OnEvent("all", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(w *msg.Wrapper) error {
+				gid := goroutineID()
+				wrappers, futures := fj.fanOut(ctx, 1, 2, 3)
+
+				futures[0].All(futures[1:]...).Then(func(res *msg.Wrapper) {
+					sum := 0
+					for _, rw := range wrappers {
+						sum += msg.GetResCustomField[int](rw, "val")
+					}
+					futureResults <- futureResult{sum: sum, err: res.Err, goid: goroutineID(), actorGid: gid}
+				})
+				return nil
+			},
+		}
+	})
+
+	fj.OnEvent("any", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(w *msg.Wrapper) error {
+				gid := goroutineID()
+				_, futures := fj.fanOut(ctx, 2, 3)
+
+				futures[0].Any(futures[1]).Then(func(res *msg.Wrapper) {
+					futureResults <- futureResult{sum: msg.GetResCustomField[int](res, "val"), err: res.Err, goid: goroutineID(), actorGid: gid}
+				})
+				return nil
+			},
+		}
+	})
+
+	fj.OnEvent("timeout", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(w *msg.Wrapper) error {
+				gid := goroutineID()
+				_, futures := fj.fanOut(ctx, 2)
+
+				futures[0].WithTimeout(time.Millisecond * 100).Then(func(res *msg.Wrapper) {
+					futureResults <- futureResult{err: res.Err, goid: goroutineID(), actorGid: gid}
+				})
+				return nil
+			},
+		}
+	})
+
+	fj.OnEvent("cancel", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(w *msg.Wrapper) error {
+				gid := goroutineID()
+				_, futures := fj.fanOut(ctx, 2)
+
+				futures[0].Then(func(res *msg.Wrapper) {
+					futureResults <- futureResult{err: res.Err, goid: goroutineID(), actorGid: gid}
+				})
+				if !futures[0].Cancel() || futures[0].Cancel() {
+					return errors.New("cancel should only succeed once")
+				}
+				return nil
+			},
+		}
+	})
+}
+
+func TestFuture(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockFutureWorker"] = &core.ActorConstructor{
+		ID:          "MockFutureWorker",
+		Name:        "MockFutureWorker",
+		Weight:      20,
+		Constructor: newMockFutureWorker,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	factory.Constructors["MockFutureJoiner"] = &core.ActorConstructor{
+		ID:          "MockFutureJoiner",
+		Name:        "MockFutureJoiner",
+		Weight:      20,
+		Constructor: newMockFutureJoiner,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-future-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	for i := 1; i <= 3; i++ {
+		_, err := nod.System().Loader("MockFutureWorker").WithID("future-worker-" + strconv.Itoa(i)).Register(context.TODO())
+		assert.Nil(t, err)
+	}
+	_, err := nod.System().Loader("MockFutureJoiner").WithID("future-joiner").Register(context.TODO())
+	assert.Nil(t, err)
+
+	run := func(t *testing.T, event string) futureResult {
+		err := nod.System().Call("future-joiner", "MockFutureJoiner", event, msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+
+		select {
+		case res := <-futureResults:
+			// continuations run on the joiner's goroutine
+			assert.Equal(t, res.actorGid, res.goid)
+			return res
+		case <-time.After(time.Second * 3):
+			t.Fatalf("future %v not completed", event)
+		}
+		return futureResult{}
+	}
+
+	t.Run("all", func(t *testing.T) {
+		res := run(t, "all")
+		assert.Nil(t, res.err)
+		assert.Equal(t, 12, res.sum) // (1 + 2 + 3) * 2
+	})
+
+	t.Run("any", func(t *testing.T) {
+		res := run(t, "any")
+		assert.Nil(t, res.err)
+		assert.Equal(t, 6, res.sum) // the fast worker wins
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		res := run(t, "timeout")
+		assert.True(t, errors.Is(res.err, core.ErrFutureTimeout), res.err)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		res := run(t, "cancel")
+		assert.True(t, errors.Is(res.err, core.ErrFutureCanceled), res.err)
+	})
+}
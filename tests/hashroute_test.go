@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHashActor struct {
+	*actor.Runtime
+}
+
+func newMockHashActor(p core.IActorBuilder) core.IActor {
+	return &mockHashActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ha *mockHashActor) Init(ctx context.Context) {
+	ha.Runtime.Init(ctx)
+
+	ha.OnEvent("whoami", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "id", Value: ctx.ID()})
+				return nil
+			},
+		}
+	})
+}
+
+func TestHashRouting(t *testing.T) {
+	store := addressbook.NewMemoryStore()
+	ctx := context.TODO()
+
+	build := func(id string) core.INode {
+		factory := mock.BuildActorFactory()
+		factory.Constructors["MockHashActor"] = &core.ActorConstructor{
+			ID:          "MockHashActor",
+			Name:        "MockHashActor",
+			Weight:      20,
+			Constructor: newMockHashActor,
+			Dynamic:     true,
+			Options:     make(map[string]string),
+		}
+		port, _ := getFreePort()
+
+		nod := node.BuildProcessWithOption(
+			core.NodeWithID(id),
+			core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+			core.NodeWithFactory(factory),
+			core.NodeWithPort(port),
+			core.NodeWithAddressBook(addressbook.Memory(store)),
+		)
+		assert.Nil(t, nod.Init())
+		return nod
+	}
+
+	nod1, nod2 := build("test-hash-1"), build("test-hash-2")
+	defer func() {
+		for _, nod := range []core.INode{nod1, nod2} {
+			wg := sync.WaitGroup{}
+			nod.System().Exit(&wg)
+			wg.Wait()
+		}
+	}()
+
+	// the shards are spread over both nodes
+	for i, nod := range []core.INode{nod1, nod2, nod1, nod2} {
+		_, err := nod.System().Loader("MockHashActor").WithID("hash-shard-" + strconv.Itoa(i)).Register(ctx)
+		assert.Nil(t, err)
+	}
+
+	route := func(sys core.ISystem, key string) string {
+		mw := msg.NewBuilder(ctx).WithReqRoutingKey(key).Build()
+		assert.Nil(t, sys.Call(def.SymbolHash, "MockHashActor", "whoami", mw))
+		return msg.GetResCustomField[string](mw, "id")
+	}
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		key := "room_" + strconv.Itoa(i)
+		owner := route(nod1.System(), key)
+		assert.NotEmpty(t, owner)
+
+		// sticky, whatever node routes the key
+		assert.Equal(t, owner, route(nod1.System(), key))
+		assert.Equal(t, owner, route(nod2.System(), key))
+
+		owners[key] = owner
+		counts[owner]++
+	}
+	assert.Len(t, counts, 4)
+
+	// a new shard only takes keys, the other keys stay where they were
+	_, err := nod2.System().Loader("MockHashActor").WithID("hash-shard-4").Register(ctx)
+	assert.Nil(t, err)
+
+	moved := 0
+	for key, owner := range owners {
+		now := route(nod1.System(), key)
+		if now != owner {
+			assert.Equal(t, "hash-shard-4", now)
+			moved++
+		}
+	}
+	assert.Greater(t, moved, 0)
+	assert.Less(t, moved, 100)
+
+	// sends are routed the same way
+	assert.Nil(t, nod1.System().Send(def.SymbolHash, "MockHashActor", "whoami",
+		msg.NewBuilder(ctx).WithReqRoutingKey("room_0").Build()))
+	assert.NotNil(t, nod1.System().Call(def.SymbolHash, "MockHashActor", "whoami", msg.NewBuilder(ctx).Build()))
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLease(t *testing.T) {
+	ctx := context.TODO()
+
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockWatchActor"] = &core.ActorConstructor{
+		ID:          "MockWatchActor",
+		Name:        "MockWatchActor",
+		Weight:      20,
+		Constructor: newMockWatchActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+
+	downs := make(chan core.NodeDown, 64)
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-lease-1"),
+		core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+		core.NodeWithFactory(factory),
+		core.NodeWithLease(time.Millisecond*300),
+		core.NodeWithNodeDown(func(down core.NodeDown) {
+			downs <- down
+		}),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	_, err := sys.Loader("MockWatchActor").WithID("lease-watcher").Register(ctx)
+	assert.Nil(t, err)
+
+	// a node which never took a lease (e.g. an older version) is not reaped
+	leaseless := addressbook.New(core.AddressInfo{Node: "test-lease-none", Ip: "127.0.0.1", Port: 2})
+	assert.Nil(t, leaseless.Register(ctx, "mocka", "lease-none-actor", 20))
+	defer leaseless.Clear(ctx)
+
+	// a crashed node, its actor is registered but its lease expires
+	ghost := addressbook.New(core.AddressInfo{Node: "test-lease-ghost", Ip: "127.0.0.1", Port: 1})
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Millisecond*100))
+	assert.Nil(t, ghost.Register(ctx, "mocka", "lease-ghost-actor", 20))
+	assert.Nil(t, ghost.JoinGroup(ctx, "lease-group", "lease-ghost-actor"))
+	assert.Nil(t, sys.AddressBook().JoinGroup(ctx, "lease-group", "lease-watcher"))
+
+	assert.Nil(t, sys.Call("lease-watcher", "MockWatchActor", "watch",
+		msg.NewBuilder(ctx).WithReqBody([]byte("lease-ghost-actor")).Build()))
+
+	// the nodes left behind by the other tests in the shared redis are reported too
+	timeout := time.After(time.Second * 5)
+	for {
+		select {
+		case down := <-downs:
+			if down.Node != "test-lease-ghost" {
+				continue
+			}
+			assert.Len(t, down.Actors, 1)
+			assert.Equal(t, "lease-ghost-actor", down.Actors[0].ActorId)
+		case <-timeout:
+			t.Fatal("node down was not reported")
+		}
+		break
+	}
+
+	_, err = sys.AddressBook().GetByID(ctx, "lease-ghost-actor")
+	assert.ErrorIs(t, err, core.ErrUnknownActor)
+	// only the memberships of the reaped node are removed
+	members, err := sys.AddressBook().GetGroup(ctx, "lease-group")
+	assert.Nil(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "lease-watcher", members[0].ActorId)
+
+	for {
+		select {
+		case term := <-watchTerminated:
+			if term.ID != "lease-ghost-actor" {
+				continue
+			}
+			assert.Equal(t, core.TerminatedNodeDown, term.Reason)
+		case <-time.After(time.Second * 2):
+			t.Fatal("watcher was not notified of the node down")
+		}
+		break
+	}
+
+	// the live node keeps its records
+	_, err = sys.AddressBook().GetByID(ctx, "lease-watcher")
+	assert.Nil(t, err)
+	_, err = sys.AddressBook().GetByID(ctx, "lease-none-actor")
+	assert.Nil(t, err)
+
+	// the ghost was only paused, its heartbeat registers its records again
+	assert.ErrorIs(t, ghost.KeepAlive(ctx, time.Minute), core.ErrLeaseLost)
+	defer ghost.Clear(ctx)
+	info, err := sys.AddressBook().GetByID(ctx, "lease-ghost-actor")
+	assert.Nil(t, err)
+	assert.Equal(t, "test-lease-ghost", info.Node)
+	members, err = sys.AddressBook().GetGroup(ctx, "lease-group")
+	assert.Nil(t, err)
+	assert.Len(t, members, 2)
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Minute))
+
+	// a cleared node has nothing left to restore, a heartbeat after its reap does not bring the records back
+	assert.Nil(t, ghost.Clear(ctx))
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Millisecond*100))
+	assert.Eventually(t, func() bool {
+		return trdredis.ZScore(ctx, def.RedisAddressbookLeasesField, "test-lease-ghost").Err() == redis.Nil
+	}, time.Second*3, time.Millisecond*20)
+	assert.ErrorIs(t, ghost.KeepAlive(ctx, time.Minute), core.ErrLeaseLost)
+	_, err = sys.AddressBook().GetByID(ctx, "lease-ghost-actor")
+	assert.ErrorIs(t, err, core.ErrUnknownActor)
+}
+
+func TestNodeLeaseMemory(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	downs := make(chan core.NodeDown, 8)
+	factory := mock.BuildActorFactory()
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-lease-mem-1"),
+		core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+		core.NodeWithFactory(factory),
+		core.NodeWithAddressBook(addressbook.Memory(store)),
+		core.NodeWithLease(time.Millisecond*300),
+		core.NodeWithNodeDown(func(down core.NodeDown) {
+			downs <- down
+		}),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	// the ghost node stops its heartbeat
+	ghost := addressbook.NewMemory(core.AddressInfo{Node: "test-lease-mem-ghost"}, store)
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Millisecond*200))
+	assert.Nil(t, ghost.Register(ctx, "mocka", "lease-mem-ghost-actor", 20))
+
+	select {
+	case down := <-downs:
+		assert.Equal(t, "test-lease-mem-ghost", down.Node)
+		assert.Len(t, down.Actors, 1)
+	case <-time.After(time.Second * 3):
+		t.Fatal("node down was not reported")
+	}
+
+	cnt, err := nod.System().AddressBook().GetActorTypeCount(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	// a node is reported once
+	downsAfter, _, err := ghost.NodeDowns(ctx, 0)
+	assert.Nil(t, err)
+	assert.Len(t, downsAfter, 1)
+
+	// the ghost was only paused, its heartbeat registers its records again
+	assert.ErrorIs(t, ghost.KeepAlive(ctx, time.Minute), core.ErrLeaseLost)
+	cnt, err = nod.System().AddressBook().GetActorTypeCount(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Minute))
+
+	// a cleared node has nothing left to restore, a heartbeat after its reap does not bring the records back
+	assert.Nil(t, ghost.Clear(ctx))
+	assert.Nil(t, ghost.KeepAlive(ctx, time.Millisecond*200))
+	time.Sleep(time.Millisecond * 600) // the expired lease of a node without records is dropped silently
+	assert.ErrorIs(t, ghost.KeepAlive(ctx, time.Minute), core.ErrLeaseLost)
+	cnt, err = nod.System().AddressBook().GetActorTypeCount(ctx, "mocka")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var lifecycleEvents = struct {
+	sync.Mutex
+	events map[string][]string
+}{events: make(map[string][]string)}
+
+func recordLifecycle(id, event string) {
+	lifecycleEvents.Lock()
+	defer lifecycleEvents.Unlock()
+	lifecycleEvents.events[id] = append(lifecycleEvents.events[id], event)
+}
+
+func lifecycleOf(id string) []string {
+	lifecycleEvents.Lock()
+	defer lifecycleEvents.Unlock()
+	return append([]string(nil), lifecycleEvents.events[id]...)
+}
+
+type mockLifecycleActor struct {
+	*actor.Runtime
+}
+
+func newMockLifecycleActor(p core.IActorBuilder) core.IActor {
+	return &mockLifecycleActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (la *mockLifecycleActor) Init(ctx context.Context) {
+	la.Runtime.Init(ctx)
+
+	tick := la.OnTimer(1000, 1000, func(i interface{}) error { return nil }, nil)
+
+	la.OnStart(func() error {
+		switch la.Id {
+		case "lifecycle-fail":
+			return errors.New("load state failed")
+		case "lifecycle-slow":
+			time.Sleep(time.Millisecond * 500)
+		}
+		recordLifecycle(la.Id, "start")
+		return nil
+	})
+
+	la.OnStop(func() error {
+		if tick.IsActive() {
+			recordLifecycle(la.Id, "stop")
+		}
+		return nil
+	})
+
+	la.OnRestart(func(f core.Failure) error {
+		recordLifecycle(la.Id, "restart "+f.Reason.(string))
+		return nil
+	})
+
+	la.OnEvent("ping", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				recordLifecycle(la.Id, "ping")
+				return nil
+			},
+		}
+	})
+
+	la.OnEvent("boom", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				panic("boom")
+			},
+		}
+	})
+}
+
+func TestLifecycle(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockLifecycleActor"] = &core.ActorConstructor{
+		ID:          "MockLifecycleActor",
+		Name:        "MockLifecycleActor",
+		Weight:      20,
+		Constructor: newMockLifecycleActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-lifecycle-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	t.Run("start and stop", func(t *testing.T) {
+		_, err := sys.Loader("MockLifecycleActor").WithID("lifecycle-ok").Register(ctx)
+		assert.Nil(t, err)
+
+		assert.Nil(t, sys.Call("lifecycle-ok", "MockLifecycleActor", "ping", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Unregister("lifecycle-ok", "MockLifecycleActor"))
+
+		// the stop hook runs after the mailbox drained, while the timers are still active
+		assert.Equal(t, []string{"start", "ping", "stop"}, lifecycleOf("lifecycle-ok"))
+	})
+
+	t.Run("start failure rolls back", func(t *testing.T) {
+		_, err := sys.Loader("MockLifecycleActor").WithID("lifecycle-fail").Register(ctx)
+		assert.NotNil(t, err)
+
+		_, err = sys.FindActor(ctx, "lifecycle-fail")
+		assert.NotNil(t, err)
+		_, err = sys.AddressBook().GetByID(ctx, "lifecycle-fail")
+		assert.True(t, errors.Is(err, core.ErrUnknownActor))
+	})
+
+	t.Run("start timeout rolls back", func(t *testing.T) {
+		tctx, cancel := context.WithTimeout(ctx, time.Millisecond*200)
+		defer cancel()
+
+		_, err := sys.Loader("MockLifecycleActor").WithID("lifecycle-slow").Register(tctx)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+
+		_, err = sys.AddressBook().GetByID(ctx, "lifecycle-slow")
+		assert.True(t, errors.Is(err, core.ErrUnknownActor))
+
+		time.Sleep(time.Millisecond * 500)
+		// the actor never started, its stop hook does not run
+		assert.Equal(t, []string{"start"}, lifecycleOf("lifecycle-slow"))
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		_, err := sys.Loader("MockLifecycleActor").WithID("lifecycle-restart").
+			WithSupervisor(&core.SupervisorStrategy{Directive: core.SupervisorRestart}).Register(ctx)
+		assert.Nil(t, err)
+
+		assert.Nil(t, sys.Send("lifecycle-restart", "MockLifecycleActor", "boom", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Send("lifecycle-restart", "MockLifecycleActor", "ping", msg.NewBuilder(ctx).Build()))
+		time.Sleep(time.Millisecond * 200)
+
+		// the fresh instance runs its restart hook before the handed over message
+		assert.Equal(t, []string{"start", "restart boom", "ping"}, lifecycleOf("lifecycle-restart"))
+	})
+}
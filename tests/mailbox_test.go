@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMailboxActor struct {
+	*actor.Runtime
+	release chan struct{}
+	handled int32
+}
+
+func newMockMailboxActor(p core.IActorBuilder) core.IActor {
+	return &mockMailboxActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+		release: make(chan struct{}),
+	}
+}
+
+func (ma *mockMailboxActor) Init(ctx context.Context) {
+	ma.Runtime.Init(ctx)
+
+	ma.OnEvent("hold", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				<-ma.release
+				return nil
+			},
+		}
+	})
+
+	ma.OnEvent("count", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				atomic.AddInt32(&ma.handled, 1)
+				return nil
+			},
+		}
+	})
+}
+
+func TestMailboxOverflow(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockMailboxActor"] = &core.ActorConstructor{
+		ID:              "MockMailboxActor",
+		Name:            "MockMailboxActor",
+		Weight:          20,
+		Constructor:     newMockMailboxActor,
+		Dynamic:         true,
+		MailboxCapacity: 2,
+		MailboxOverflow: core.MailboxReject,
+		Options:         make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-mailbox-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+
+	rejectActor, err := nod.System().Loader("MockMailboxActor").WithID("mailbox-reject").Register(context.TODO())
+	assert.Nil(t, err)
+	dropActor, err := nod.System().Loader("MockMailboxActor").WithID("mailbox-drop").
+		WithMailbox(2, core.MailboxDropOldest).Register(context.TODO())
+	assert.Nil(t, err)
+
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	t.Run("reject", func(t *testing.T) {
+		ra := rejectActor.(*mockMailboxActor)
+
+		err := nod.System().Send("mailbox-reject", "MockMailboxActor", "hold", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond * 100) // wait for hold to be popped
+
+		for i := 0; i < 2; i++ {
+			err = nod.System().Send("mailbox-reject", "MockMailboxActor", "count", msg.NewBuilder(context.TODO()).Build())
+			assert.Nil(t, err)
+		}
+
+		err = nod.System().Send("mailbox-reject", "MockMailboxActor", "count", msg.NewBuilder(context.TODO()).Build())
+		assert.True(t, errors.Is(err, core.ErrMailboxFull))
+		assert.Equal(t, uint64(1), ra.MailboxDropped())
+
+		close(ra.release)
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&ra.handled))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		da := dropActor.(*mockMailboxActor)
+
+		err := nod.System().Send("mailbox-drop", "MockMailboxActor", "hold", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond * 100)
+
+		for i := 0; i < 5; i++ {
+			err = nod.System().Send("mailbox-drop", "MockMailboxActor", "count", msg.NewBuilder(context.TODO()).Build())
+			assert.Nil(t, err)
+		}
+
+		close(da.release)
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&da.handled))
+		assert.Equal(t, uint64(3), da.MailboxDropped())
+	})
+}
@@ -45,8 +45,11 @@ func (a *dynamicPickerActor) Init(ctx context.Context) {
 					return err
 				}
 
-				// rename
-				msgbuild := mw.ToBuilder().WithReqCustomFields(def.ActorID(nodeaddr.Node + "_" + actor_ty + "_" + uuid.NewString()))
+				// keep the id chosen by the caller, otherwise generate one for the picked node
+				msgbuild := mw.ToBuilder()
+				if msg.GetReqCustomField[string](mw, def.KeyActorID) == "" {
+					msgbuild.WithReqCustomFields(def.ActorID(nodeaddr.Node + "_" + actor_ty + "_" + uuid.NewString()))
+				}
 
 				// dispatcher to picker node
 				return ctx.Call(nodeaddr.Node+"_"+"MockDynamicRegister", "MockDynamicRegister", "MockDynamicRegister", msgbuild.Build())
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var multicastReceived int32
+
+type mockMulticastActor struct {
+	*actor.Runtime
+}
+
+func newMockMulticastActor(p core.IActorBuilder) core.IActor {
+	return &mockMulticastActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ma *mockMulticastActor) Init(ctx context.Context) {
+	ma.Runtime.Init(ctx)
+
+	ma.OnEvent("join", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.JoinGroup(msg.GetReqCustomField[string](mw, "group"))
+			},
+		}
+	})
+
+	ma.OnEvent("notify", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				atomic.AddInt32(&multicastReceived, 1)
+				return nil
+			},
+		}
+	})
+}
+
+// groupCountingBook counts the group lookups of a node
+type groupCountingBook struct {
+	core.IAddressBook
+	lookups int32
+}
+
+func (b *groupCountingBook) GetGroup(ctx context.Context, group string) ([]core.AddressInfo, error) {
+	atomic.AddInt32(&b.lookups, 1)
+	return b.IAddressBook.GetGroup(ctx, group)
+}
+
+func TestMulticast(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockMulticastActor"] = &core.ActorConstructor{
+		ID:          "MockMulticastActor",
+		Name:        "MockMulticastActor",
+		Weight:      20,
+		Constructor: newMockMulticastActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	p1, _ := getFreePort()
+	p2, _ := getFreePort()
+
+	nod1 := node.BuildProcessWithOption(
+		core.NodeWithID("test-multicast-1"),
+		core.NodeWithPort(p1),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	book2 := &groupCountingBook{}
+	nod2 := node.BuildProcessWithOption(
+		core.NodeWithID("test-multicast-2"),
+		core.NodeWithPort(p2),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+		core.NodeWithAddressBook(func(info core.AddressInfo) core.IAddressBook {
+			book2.IAddressBook = addressbook.New(info)
+			return book2
+		}),
+	)
+
+	var err error
+	_, err = nod1.System().Loader("MockMulticastActor").WithID("multicast-1").Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = nod1.System().Loader("MockMulticastActor").WithID("multicast-2").Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = nod2.System().Loader("MockMulticastActor").WithID("multicast-3").Register(context.TODO())
+	assert.Nil(t, err)
+
+	nod1.Init()
+	nod2.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod1.System().Exit(&wg)
+		nod2.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	t.Run("all", func(t *testing.T) {
+		atomic.StoreInt32(&multicastReceived, 0)
+
+		err := nod1.System().Send(def.SymbolAll, "MockMulticastActor", "notify", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+
+		time.Sleep(time.Millisecond * 500)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&multicastReceived))
+	})
+
+	t.Run("group", func(t *testing.T) {
+		atomic.StoreInt32(&multicastReceived, 0)
+
+		for _, id := range []string{"multicast-1", "multicast-3"} {
+			m := msg.NewBuilder(context.TODO()).WithReqCustomFields(msg.Attr{Key: "group", Value: "room_1"}).Build()
+			err := nod1.System().Call(id, "MockMulticastActor", "join", m)
+			assert.Nil(t, err)
+		}
+
+		err := nod1.System().Send(def.SymbolGroup+"room_1", "MockMulticastActor", "notify", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+
+		time.Sleep(time.Millisecond * 500)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&multicastReceived))
+
+		// the members are resolved by the sender, the receiving node does not look the group up again
+		assert.Equal(t, int32(0), atomic.LoadInt32(&book2.lookups))
+	})
+
+	t.Run("call", func(t *testing.T) {
+		err := nod1.System().Call(def.SymbolAll, "MockMulticastActor", "notify", msg.NewBuilder(context.TODO()).Build())
+		assert.NotNil(t, err)
+
+		err = nod1.System().Send(def.SymbolAll, "", "notify", msg.NewBuilder(context.TODO()).Build())
+		assert.NotNil(t, err)
+	})
+}
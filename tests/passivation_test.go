@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var passivated int32
+
+type mockPassivateActor struct {
+	*actor.Runtime
+}
+
+func newMockPassivateActor(p core.IActorBuilder) core.IActor {
+	return &mockPassivateActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (pa *mockPassivateActor) Init(ctx context.Context) {
+	pa.Runtime.Init(ctx)
+
+	pa.OnEvent("ping", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return nil
+			},
+		}
+	})
+
+	pa.OnPassivate(func() error {
+		if pa.ID() == "passivate-refuse" {
+			return errors.New("state not saved")
+		}
+		atomic.AddInt32(&passivated, 1)
+		return nil
+	})
+}
+
+func TestPassivation(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockPassivateActor"] = &core.ActorConstructor{
+		ID:          "MockPassivateActor",
+		Name:        "MockPassivateActor",
+		Weight:      20,
+		Constructor: newMockPassivateActor,
+		Dynamic:     true,
+		IdleTimeout: time.Millisecond * 300,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-passivation-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	// registered after init so that the idle timeout is not consumed by the node startup
+	var err error
+	for _, id := range []string{"passivate-idle", "passivate-busy", "passivate-refuse"} {
+		_, err = nod.System().Loader("MockPassivateActor").WithID(id).Register(context.TODO())
+		assert.Nil(t, err)
+	}
+
+	// keep one actor busy for longer than its idle timeout
+	for i := 0; i < 8; i++ {
+		err = nod.System().Call("passivate-busy", "MockPassivateActor", "ping", msg.NewBuilder(context.TODO()).Build())
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	_, err = nod.System().FindActor(context.TODO(), "passivate-idle")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&passivated))
+
+	_, err = nod.System().FindActor(context.TODO(), "passivate-busy")
+	assert.Nil(t, err)
+
+	// a failing hook keeps the actor alive
+	_, err = nod.System().FindActor(context.TODO(), "passivate-refuse")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPriorityActor struct {
+	*actor.Runtime
+	release chan struct{}
+
+	mu      sync.Mutex
+	handled []string
+}
+
+func newMockPriorityActor(p core.IActorBuilder) core.IActor {
+	return &mockPriorityActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+		release: make(chan struct{}),
+	}
+}
+
+func (pa *mockPriorityActor) record(event string) {
+	pa.mu.Lock()
+	pa.handled = append(pa.handled, event)
+	pa.mu.Unlock()
+}
+
+func (pa *mockPriorityActor) events() []string {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	return append([]string{}, pa.handled...)
+}
+
+func (pa *mockPriorityActor) Init(ctx context.Context) {
+	pa.Runtime.Init(ctx)
+
+	pa.OnEvent("hold", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				<-pa.release
+				return nil
+			},
+		}
+	})
+
+	for _, event := range []string{"chat", "kick"} {
+		event := event
+		pa.OnEvent(event, func(ctx core.ActorContext) core.IChain {
+			return &actor.DefaultChain{
+				Handler: func(mw *msg.Wrapper) error {
+					pa.record(event)
+					return nil
+				},
+			}
+		})
+	}
+}
+
+func TestPriorityLanes(t *testing.T) {
+	const chats = 100
+
+	dispatcher := actor.NewDispatcher(actor.DispatcherWithWorkers(2))
+	defer dispatcher.Exit()
+
+	factory := mock.BuildActorFactory()
+	for _, ty := range []struct {
+		name       string
+		dispatcher core.IDispatcher
+	}{
+		{"MockPriorityActor", nil},
+		{"MockPriorityDispatchActor", dispatcher},
+	} {
+		factory.Constructors[ty.name] = &core.ActorConstructor{
+			ID:              ty.name,
+			Name:            ty.name,
+			Weight:          20,
+			Constructor:     newMockPriorityActor,
+			Dynamic:         true,
+			MailboxCapacity: chats,
+			MailboxOverflow: core.MailboxReject,
+			Dispatcher:      ty.dispatcher,
+			Options:         make(map[string]string),
+		}
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-priority-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	for _, ty := range []string{"MockPriorityActor", "MockPriorityDispatchActor"} {
+		t.Run(ty, func(t *testing.T) {
+			a, err := sys.Loader(ty).WithID("priority-" + ty).Register(ctx)
+			assert.Nil(t, err)
+			pa := a.(*mockPriorityActor)
+
+			assert.Nil(t, sys.Send(pa.ID(), ty, "hold", msg.NewBuilder(ctx).Build()))
+			time.Sleep(time.Millisecond * 50) // wait for hold to be popped
+
+			for i := 0; i < chats; i++ {
+				assert.Nil(t, sys.Send(pa.ID(), ty, "chat", msg.NewBuilder(ctx).Build()))
+			}
+
+			// the mailbox is full, the high priority request is not bound by its capacity
+			assert.Nil(t, sys.Send(pa.ID(), ty, "kick", msg.NewBuilder(ctx).WithReqPriority(msg.PriorityHigh).Build()))
+
+			close(pa.release)
+			assert.Eventually(t, func() bool {
+				return len(pa.events()) == chats+1
+			}, time.Second, time.Millisecond*10)
+
+			// handled before the queued chat messages
+			assert.Equal(t, "kick", pa.events()[0])
+		})
+	}
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -64,8 +65,9 @@ func TestReenter(t *testing.T) {
 		err := nod.System().Call("mocka", "mocka", "chain", msg.NewBuilder(context.TODO()).Build())
 		assert.Nil(t, err)
 
-		assert.Nil(t, err)
-		time.Sleep(time.Second)
-		assert.Equal(t, int32(18), mock.RecenterCalcValue) // ((2 + 2) * 2) + 10
+		// mockb may still be sleeping on the message of the timeout case, the chain waits for it in its mailbox
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&mock.RecenterCalcValue) == 18 // ((2 + 2) * 2) + 10
+		}, time.Second*3, time.Millisecond*10)
 	})
 }
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/lib/dismutex"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var reminderFires = struct {
+	sync.Mutex
+	cnt map[string]int
+}{cnt: make(map[string]int)}
+
+func reminderFired(key string) int {
+	reminderFires.Lock()
+	defer reminderFires.Unlock()
+	return reminderFires.cnt[key]
+}
+
+type mockReminderActor struct {
+	*actor.Runtime
+}
+
+func newMockReminderActor(p core.IActorBuilder) core.IActor {
+	return &mockReminderActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ra *mockReminderActor) Init(ctx context.Context) {
+	ra.Runtime.Init(ctx)
+
+	ra.OnEvent("remind", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				reminderFires.Lock()
+				reminderFires.cnt[ctx.ID()+"."+msg.GetReqCustomField[string](mw, def.KeyReminder)]++
+				reminderFires.Unlock()
+				return nil
+			},
+		}
+	})
+}
+
+func buildReminderFactory() *mock.MockActorFactory {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockReminderActor"] = &core.ActorConstructor{
+		ID:               "MockReminderActor",
+		Name:             "MockReminderActor",
+		Weight:           20,
+		Constructor:      newMockReminderActor,
+		Dynamic:          true,
+		ActivateOnDemand: true,
+		Options:          make(map[string]string),
+	}
+	return factory
+}
+
+func TestReminder(t *testing.T) {
+	factory := buildReminderFactory()
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	p1, _ := getFreePort()
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-reminder-1"),
+		core.NodeWithPort(p1),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+
+	_, err := nod.System().Loader("MockReminderActor").WithID("reminder-1").Register(context.TODO())
+	assert.Nil(t, err)
+
+	ctx := context.TODO()
+	sys := nod.System()
+
+	t.Run("fire", func(t *testing.T) {
+		err := sys.RegisterReminder(ctx, core.Reminder{
+			ActorID: "reminder-1", ActorTy: "MockReminderActor", Name: "once", Event: "remind",
+			Due: time.Now().Add(time.Millisecond * 300),
+		})
+		assert.Nil(t, err)
+
+		err = sys.RegisterReminder(ctx, core.Reminder{
+			ActorID: "reminder-1", ActorTy: "MockReminderActor", Name: "tick", Event: "remind",
+			Due: time.Now().Add(time.Millisecond * 200), Period: time.Millisecond * 400,
+		})
+		assert.Nil(t, err)
+
+		reminders, err := sys.Reminders(ctx, "reminder-1")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(reminders))
+
+		time.Sleep(time.Millisecond * 1300)
+
+		assert.Equal(t, 1, reminderFired("reminder-1.once"))
+		ticks := reminderFired("reminder-1.tick")
+		assert.True(t, ticks >= 2 && ticks <= 4, ticks)
+
+		// the one-shot reminder is removed once delivered
+		reminders, err = sys.Reminders(ctx, "reminder-1")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(reminders))
+
+		assert.Nil(t, sys.CancelReminder(ctx, "reminder-1", "tick"))
+		time.Sleep(time.Millisecond * 200) // a fire claimed before the cancel may still be in flight
+		ticks = reminderFired("reminder-1.tick")
+		time.Sleep(time.Millisecond * 800)
+		assert.Equal(t, ticks, reminderFired("reminder-1.tick"))
+
+		reminders, err = sys.Reminders(ctx, "reminder-1")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(reminders))
+	})
+
+	t.Run("dedup", func(t *testing.T) {
+		// a fire delivered by a claim whose acknowledgement was lost is not delivered again, only acknowledged
+		due := time.Now().Add(time.Millisecond * 200)
+		fire := "reminder-1:dedup:" + fmt.Sprint(due.UnixMilli())
+		assert.Nil(t, trdredis.SetEx(ctx, def.RedisReminderFiredField+fire, 1, time.Minute).Err())
+
+		err := sys.RegisterReminder(ctx, core.Reminder{
+			ActorID: "reminder-1", ActorTy: "MockReminderActor", Name: "dedup", Event: "remind", Due: due,
+		})
+		assert.Nil(t, err)
+
+		time.Sleep(time.Millisecond * 800)
+		assert.Equal(t, 0, reminderFired("reminder-1.dedup"))
+
+		reminders, err := sys.Reminders(ctx, "reminder-1")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(reminders))
+
+		// the mark of a delivered one-shot fire outlives its lease only
+		marks, err := trdredis.GetClient().Keys(ctx, def.RedisReminderFiredField+"reminder-1:once:*").Result()
+		assert.Nil(t, err)
+		assert.Len(t, marks, 1)
+		ttl, err := trdredis.GetClient().TTL(ctx, marks[0]).Result()
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, ttl, time.Second*20)
+	})
+
+	t.Run("crash before delivery", func(t *testing.T) {
+		// the target is activated on demand, the activation is held so that the owner of the fire is stuck in
+		// its delivery, an owner killed at this point must leave the fire to the next claim
+		token := def.RedisActivationLockField + "reminder-crash"
+		mid, err := dismutex.Lock(ctx, token)
+		assert.Nil(t, err)
+		defer dismutex.Unlock(ctx, token, mid)
+
+		err = sys.RegisterReminder(ctx, core.Reminder{
+			ActorID: "reminder-crash", ActorTy: "MockReminderActor", Name: "crash", Event: "remind",
+			Due: time.Now().Add(time.Millisecond * 100),
+		})
+		assert.Nil(t, err)
+
+		time.Sleep(time.Millisecond * 600)
+		marks, err := trdredis.GetClient().Keys(ctx, def.RedisReminderFiredField+"reminder-crash:crash:*").Result()
+		assert.Nil(t, err)
+		assert.Len(t, marks, 0)
+		assert.Equal(t, 0, reminderFired("reminder-crash.crash"))
+
+		// the owner survived, the fire is delivered and marked once the actor shows up
+		// (a node of the suite which does not know the type may have claimed it first, it is claimed again after the lease)
+		_, err = sys.Loader("MockReminderActor").WithID("reminder-crash").Register(ctx)
+		assert.Nil(t, err)
+
+		assert.Eventually(t, func() bool { return reminderFired("reminder-crash.crash") == 1 }, time.Second*15, time.Millisecond*20)
+		assert.Eventually(t, func() bool {
+			marks, _ := trdredis.GetClient().Keys(ctx, def.RedisReminderFiredField+"reminder-crash:crash:*").Result()
+			return len(marks) == 1
+		}, time.Second, time.Millisecond*20)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		// the reminder outlives the node it was registered on, and is delivered to the actor on another node
+		err := sys.RegisterReminder(ctx, core.Reminder{
+			ActorID: "reminder-moved", ActorTy: "MockReminderActor", Name: "expire", Event: "remind",
+			Due: time.Now().Add(time.Second * 3),
+		})
+		assert.Nil(t, err)
+
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+
+		p2, _ := getFreePort()
+		nod2 := node.BuildProcessWithOption(
+			core.NodeWithID("test-reminder-2"),
+			core.NodeWithPort(p2),
+			core.NodeWithLoader(loader),
+			core.NodeWithFactory(factory),
+		)
+		nod2.Init()
+		_, err = nod2.System().Loader("MockReminderActor").WithID("reminder-moved").Register(ctx)
+		assert.Nil(t, err)
+		defer func() {
+			wg := sync.WaitGroup{}
+			nod2.System().Exit(&wg)
+			wg.Wait()
+		}()
+
+		time.Sleep(time.Second * 4)
+		assert.Equal(t, 1, reminderFired("reminder-moved.expire"))
+
+		reminders, err := nod2.System().Reminders(ctx, "reminder-moved")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(reminders))
+	})
+}
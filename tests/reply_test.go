@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReplyActor struct {
+	*actor.Runtime
+	released chan struct{}
+}
+
+func newMockReplyActor(p core.IActorBuilder) core.IActor {
+	return &mockReplyActor{
+		Runtime:  &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+		released: make(chan struct{}),
+	}
+}
+
+func (ra *mockReplyActor) Init(ctx context.Context) {
+	ra.Runtime.Init(ctx)
+
+	ra.OnEvent("slow", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				time.Sleep(time.Millisecond * 500)
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "value", Value: 2})
+				return nil
+			},
+		}
+	})
+
+	// fanout fires a send from its handler, the call is answered without waiting for it
+	ra.OnEvent("fanout", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Send("reply-2", "MockReplyActor", "slow", msg.NewBuilder(mw.Ctx).Build())
+			},
+		}
+	})
+
+	// early replies before it is done with the message
+	ra.OnEvent("early", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "value", Value: 1})
+				mw.Reply()
+
+				<-ra.released
+				return nil
+			},
+		}
+	})
+
+	// deferred replies from the continuation of a reentrant call
+	ra.OnEvent("deferred", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.DeferReply()
+
+				ctx.ReenterCall("reply-2", "MockReplyActor", "slow", msg.NewBuilder(context.TODO()).Build()).
+					Then(func(res *msg.Wrapper) {
+						value := msg.GetResCustomField[int](res, "value")
+						mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "value", Value: value * 10})
+						mw.Reply()
+					})
+				return nil
+			},
+		}
+	})
+
+	ra.OnEvent("quick", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "value", Value: 3})
+				return nil
+			},
+		}
+	})
+
+	// forward calls another actor with the message it is handling
+	ra.OnEvent("forward", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Call("reply-2", "MockReplyActor", "quick", mw)
+			},
+		}
+	})
+
+	ra.OnEvent("never", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				mw.DeferReply()
+				return nil
+			},
+		}
+	})
+}
+
+func TestReply(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockReplyActor"] = &core.ActorConstructor{
+		ID:          "MockReplyActor",
+		Name:        "MockReplyActor",
+		Weight:      20,
+		Constructor: newMockReplyActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-reply-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	a, err := sys.Loader("MockReplyActor").WithID("reply-1").Register(ctx)
+	assert.Nil(t, err)
+	_, err = sys.Loader("MockReplyActor").WithID("reply-2").Register(ctx)
+	assert.Nil(t, err)
+
+	t.Run("sends of the handler are not waited on", func(t *testing.T) {
+		begin := time.Now()
+		assert.Nil(t, sys.Call("reply-1", "MockReplyActor", "fanout", msg.NewBuilder(ctx).Build()))
+		assert.Less(t, time.Since(begin), time.Millisecond*300)
+	})
+
+	t.Run("early reply", func(t *testing.T) {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, sys.Call("reply-1", "MockReplyActor", "early", mw))
+		assert.Nil(t, mw.Err)
+		assert.Equal(t, 1, msg.GetResCustomField[int](mw, "value"))
+
+		close(a.(*mockReplyActor).released)
+	})
+
+	t.Run("deferred reply", func(t *testing.T) {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, sys.Call("reply-1", "MockReplyActor", "deferred", mw))
+		assert.Nil(t, mw.Err)
+		assert.Equal(t, 20, msg.GetResCustomField[int](mw, "value"))
+	})
+
+	t.Run("nested forward", func(t *testing.T) {
+		// the nested call does not take the reply of the outer call over
+		for i := 0; i < 3; i++ {
+			mw := msg.NewBuilder(ctx).Build()
+			begin := time.Now()
+			assert.Nil(t, sys.Call("reply-1", "MockReplyActor", "forward", mw))
+			assert.Less(t, time.Since(begin), time.Millisecond*500)
+			assert.Nil(t, mw.Err)
+			assert.Equal(t, 3, msg.GetResCustomField[int](mw, "value"))
+		}
+	})
+
+	t.Run("missing reply times out", func(t *testing.T) {
+		mw := msg.NewBuilder(ctx).Build()
+		begin := time.Now()
+		sys.Call("reply-1", "MockReplyActor", "never", mw, core.CallWithTimeout(time.Millisecond*100))
+		assert.NotNil(t, mw.Err)
+		assert.Less(t, time.Since(begin), time.Second)
+	})
+}
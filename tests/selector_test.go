@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	trdredis "github.com/pojol/braid/3rd/redis"
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/addressbook"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/def"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleOneSelector picks the single actor of its sample
+type sampleOneSelector struct{}
+
+func (sampleOneSelector) Sample() int { return 1 }
+
+func (sampleOneSelector) Select(candidates []core.AddressInfo, weights map[string]int) core.AddressInfo {
+	return candidates[0]
+}
+
+func TestWildcardSelectors(t *testing.T) {
+	ctx := context.TODO()
+
+	// node 2 carries the lower weight
+	ab1 := addressbook.New(core.AddressInfo{Node: "selector-node-1", Ip: "127.0.0.1", Port: 3001})
+	ab2 := addressbook.New(core.AddressInfo{Node: "selector-node-2", Ip: "127.0.0.1", Port: 3002})
+	defer ab1.Clear(ctx)
+	defer ab2.Clear(ctx)
+
+	// the books hold a lease, or the reapers of the other nodes clear them
+	assert.Nil(t, ab1.KeepAlive(ctx, time.Minute))
+	assert.Nil(t, ab2.KeepAlive(ctx, time.Minute))
+
+	assert.Nil(t, ab1.Register(ctx, "selectorty", "selector-a1", 20))
+	assert.Nil(t, ab1.Register(ctx, "selectorty", "selector-a2", 20))
+	assert.Nil(t, ab2.Register(ctx, "selectorty", "selector-a3", 5))
+
+	for i := 0; i < 10; i++ {
+		info, err := ab1.GetWildcardActor(ctx, "selectorty", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "selector-a3", info.ActorId)
+	}
+
+	rr := addressbook.NewSelector(addressbook.SelectorRoundRobin)
+	var turns []string
+	for i := 0; i < 6; i++ {
+		info, err := ab1.GetWildcardActor(ctx, "selectorty", rr)
+		assert.Nil(t, err)
+		turns = append(turns, info.ActorId)
+	}
+	assert.Equal(t, []string{"selector-a1", "selector-a2", "selector-a3", "selector-a1", "selector-a2", "selector-a3"}, turns)
+
+	for _, name := range []string{addressbook.SelectorRandom, addressbook.SelectorPowerOfTwo} {
+		picked := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			info, err := ab1.GetWildcardActor(ctx, "selectorty", addressbook.NewSelector(name))
+			assert.Nil(t, err)
+			picked[info.ActorId] = true
+		}
+		assert.True(t, picked["selector-a3"], name)
+		assert.Greater(t, len(picked), 1, name)
+	}
+
+	_, err := ab1.GetWildcardActor(ctx, "selector-unknown", nil)
+	assert.NotNil(t, err)
+
+	// a sample which only hits the actors of a node without a weight falls back to the whole set
+	ghost, _ := json.Marshal(core.AddressInfo{Node: "selector-ghost", ActorId: "selector-ghost-a1", ActorTy: "selectorsample"})
+	assert.Nil(t, trdredis.SAdd(ctx, def.RedisAddressbookTyField+"selectorsample", string(ghost)).Err())
+	defer trdredis.Del(ctx, def.RedisAddressbookTyField+"selectorsample")
+	assert.Nil(t, ab2.Register(ctx, "selectorsample", "selector-s1", 5))
+	for i := 0; i < 20; i++ {
+		info, err := ab1.GetWildcardActor(ctx, "selectorsample", sampleOneSelector{})
+		assert.Nil(t, err)
+		assert.Equal(t, "selector-s1", info.ActorId)
+	}
+
+	// the memory book picks the same way
+	store := addressbook.NewMemoryStore()
+	mem := addressbook.NewMemory(core.AddressInfo{Node: "selector-mem-1"}, store)
+	assert.Nil(t, mem.Register(ctx, "selectorty", "selector-mem-a1", 20))
+	assert.Nil(t, mem.Register(ctx, "selectorty", "selector-mem-a2", 20))
+	rr = addressbook.NewSelector(addressbook.SelectorRoundRobin)
+	for _, want := range []string{"selector-mem-a1", "selector-mem-a2", "selector-mem-a1"} {
+		info, err := mem.GetWildcardActor(ctx, "selectorty", rr)
+		assert.Nil(t, err)
+		assert.Equal(t, want, info.ActorId)
+	}
+}
+
+func TestWildcardSelectorOption(t *testing.T) {
+	ctx := context.TODO()
+	store := addressbook.NewMemoryStore()
+
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockHashActor"] = &core.ActorConstructor{
+		ID:          "MockHashActor",
+		Name:        "MockHashActor",
+		Weight:      20,
+		Constructor: newMockHashActor,
+		Dynamic:     true,
+		Options:     map[string]string{core.SelectorOption: addressbook.SelectorRoundRobin},
+	}
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("selector-option-1"),
+		core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+		core.NodeWithFactory(factory),
+		core.NodeWithAddressBook(addressbook.Memory(store)),
+	)
+	assert.Nil(t, nod.Init())
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	for _, id := range []string{"selector-rr-1", "selector-rr-2"} {
+		_, err := nod.System().Loader("MockHashActor").WithID(id).Register(ctx)
+		assert.Nil(t, err)
+	}
+
+	// the wildcard calls to the type take the actors in turns
+	var turns []string
+	for i := 0; i < 4; i++ {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, nod.System().Call(def.SymbolWildcard, "MockHashActor", "whoami", mw))
+		turns = append(turns, msg.GetResCustomField[string](mw, "id"))
+	}
+	assert.Equal(t, []string{"selector-rr-1", "selector-rr-2", "selector-rr-1", "selector-rr-2"}, turns)
+}
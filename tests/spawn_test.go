@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSpawnParent struct {
+	*actor.Runtime
+}
+
+func newMockSpawnParent(p core.IActorBuilder) core.IActor {
+	return &mockSpawnParent{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (sp *mockSpawnParent) Init(ctx context.Context) {
+	sp.Runtime.Init(ctx)
+
+	sp.OnEvent("spawn", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				var opts []core.SpawnOption
+				if msg.GetReqCustomField[bool](mw, "local") {
+					opts = append(opts, core.SpawnWithLocal())
+				}
+
+				_, err := ctx.Spawn("MockSpawnChild", msg.GetReqCustomField[string](mw, "id"), opts...)
+				return err
+			},
+		}
+	})
+
+	sp.OnEvent("children", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				ids := []string{}
+				for _, child := range ctx.Children() {
+					ids = append(ids, child.ID())
+				}
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "children", Value: strings.Join(ids, ",")})
+				return nil
+			},
+		}
+	})
+}
+
+type mockSpawnChild struct {
+	*actor.Runtime
+	count int
+}
+
+func newMockSpawnChild(p core.IActorBuilder) core.IActor {
+	return &mockSpawnChild{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (sc *mockSpawnChild) Init(ctx context.Context) {
+	sc.Runtime.Init(ctx)
+
+	sc.OnEvent("incr", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				sc.count++
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "count", Value: sc.count})
+				return nil
+			},
+		}
+	})
+
+	sc.OnEvent("boom", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				panic("child boom")
+			},
+		}
+	})
+}
+
+func TestSpawn(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockSpawnParent"] = &core.ActorConstructor{
+		ID:          "MockSpawnParent",
+		Name:        "MockSpawnParent",
+		Weight:      20,
+		Constructor: newMockSpawnParent,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	factory.Constructors["MockSpawnChild"] = &core.ActorConstructor{
+		ID:          "MockSpawnChild",
+		Name:        "MockSpawnChild",
+		Weight:      20,
+		Constructor: newMockSpawnChild,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-spawn-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	spawn := func(t *testing.T, parent, id string, local bool) {
+		err := sys.Call(parent, "MockSpawnParent", "spawn", msg.NewBuilder(ctx).WithReqCustomFields(
+			msg.Attr{Key: "id", Value: id},
+			msg.Attr{Key: "local", Value: local},
+		).Build())
+		assert.Nil(t, err)
+	}
+
+	children := func(t *testing.T, parent string) string {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, sys.Call(parent, "MockSpawnParent", "children", mw))
+		return msg.GetResCustomField[string](mw, "children")
+	}
+
+	incr := func(t *testing.T, id string) int {
+		mw := msg.NewBuilder(ctx).Build()
+		assert.Nil(t, sys.Call(id, "MockSpawnChild", "incr", mw))
+		return msg.GetResCustomField[int](mw, "count")
+	}
+
+	t.Run("children stop with their parent", func(t *testing.T) {
+		_, err := sys.Loader("MockSpawnParent").WithID("spawn-parent-1").Register(ctx)
+		assert.Nil(t, err)
+
+		spawn(t, "spawn-parent-1", "spawn-child-1", false)
+		spawn(t, "spawn-parent-1", "spawn-child-2", true)
+		assert.Equal(t, "spawn-child-1,spawn-child-2", children(t, "spawn-parent-1"))
+
+		// the local child is reachable on this node only
+		_, err = sys.AddressBook().GetByID(ctx, "spawn-child-1")
+		assert.Nil(t, err)
+		_, err = sys.AddressBook().GetByID(ctx, "spawn-child-2")
+		assert.True(t, errors.Is(err, core.ErrUnknownActor))
+		assert.Equal(t, 1, incr(t, "spawn-child-2"))
+
+		assert.Nil(t, sys.Unregister("spawn-parent-1", "MockSpawnParent"))
+
+		for _, id := range []string{"spawn-child-1", "spawn-child-2"} {
+			_, err = sys.FindActor(ctx, id)
+			assert.NotNil(t, err)
+		}
+		_, err = sys.AddressBook().GetByID(ctx, "spawn-child-1")
+		assert.True(t, errors.Is(err, core.ErrUnknownActor))
+	})
+
+	t.Run("parent supervises its children", func(t *testing.T) {
+		_, err := sys.Loader("MockSpawnParent").WithID("spawn-parent-2").
+			WithSupervisor(&core.SupervisorStrategy{Directive: core.SupervisorRestart}).Register(ctx)
+		assert.Nil(t, err)
+
+		spawn(t, "spawn-parent-2", "spawn-child-3", true)
+		assert.Equal(t, 1, incr(t, "spawn-child-3"))
+
+		assert.Nil(t, sys.Send("spawn-child-3", "MockSpawnChild", "boom", msg.NewBuilder(ctx).Build()))
+		time.Sleep(time.Millisecond * 100)
+
+		// restarted with fresh state, and still a child of its parent
+		assert.Equal(t, 1, incr(t, "spawn-child-3"))
+		assert.Equal(t, "spawn-child-3", children(t, "spawn-parent-2"))
+	})
+
+	t.Run("escalated to the system", func(t *testing.T) {
+		_, err := sys.Loader("MockSpawnParent").WithID("spawn-parent-3").Register(ctx)
+		assert.Nil(t, err)
+
+		spawn(t, "spawn-parent-3", "spawn-child-4", false)
+		assert.Nil(t, sys.Send("spawn-child-4", "MockSpawnChild", "boom", msg.NewBuilder(ctx).Build()))
+		time.Sleep(time.Millisecond * 100)
+
+		// the parent has no strategy, the system supervisor stops the child
+		assert.Equal(t, "", children(t, "spawn-parent-3"))
+		_, err = sys.FindActor(ctx, "spawn-child-4")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStashActor struct {
+	*actor.Runtime
+
+	mu      sync.Mutex
+	handled []int
+}
+
+func newMockStashActor(p core.IActorBuilder) core.IActor {
+	return &mockStashActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (sa *mockStashActor) seqs() []int {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return append([]int{}, sa.handled...)
+}
+
+func (sa *mockStashActor) Init(ctx context.Context) {
+	sa.Runtime.Init(ctx)
+
+	// active behavior
+	sa.OnEvent("get", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				seq := msg.GetReqCustomField[int](mw, "seq")
+				sa.mu.Lock()
+				sa.handled = append(sa.handled, seq)
+				sa.mu.Unlock()
+
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "seq", Value: seq})
+				return nil
+			},
+		}
+	})
+
+	sa.OnEvent("unload", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				ctx.Become(sa.loading(ctx))
+				return nil
+			},
+		}
+	})
+
+	// the actor starts loading its state, requests are deferred until it is loaded
+	sa.Become(sa.loading(sa.Runtime.Context()))
+}
+
+func (sa *mockStashActor) loading(ctx core.ActorContext) map[string]core.IChain {
+	return map[string]core.IChain{
+		"get": &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Stash()
+			},
+		},
+		"loaded": &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				ctx.Unbecome()
+				ctx.UnstashAll()
+				return nil
+			},
+		},
+	}
+}
+
+func TestStash(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockStashActor"] = &core.ActorConstructor{
+		ID:          "MockStashActor",
+		Name:        "MockStashActor",
+		Weight:      20,
+		Constructor: newMockStashActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-stash-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	sys := nod.System()
+	ctx := context.TODO()
+
+	get := func(seq int) *msg.Wrapper {
+		return msg.NewBuilder(ctx).WithReqCustomFields(msg.Attr{Key: "seq", Value: seq}).Build()
+	}
+
+	t.Run("loading defers requests", func(t *testing.T) {
+		a, err := sys.Loader("MockStashActor").WithID("stash-1").Register(ctx)
+		assert.Nil(t, err)
+		sa := a.(*mockStashActor)
+
+		for seq := 1; seq <= 5; seq++ {
+			assert.Nil(t, sys.Send("stash-1", "MockStashActor", "get", get(seq)))
+		}
+
+		// the sender of a stashed call waits until the actor is loaded
+		called := make(chan *msg.Wrapper, 1)
+		go func() {
+			mw := get(6)
+			assert.Nil(t, sys.Call("stash-1", "MockStashActor", "get", mw))
+			called <- mw
+		}()
+
+		time.Sleep(time.Millisecond * 100)
+		assert.Empty(t, sa.seqs())
+		assert.Len(t, called, 0)
+
+		assert.Nil(t, sys.Call("stash-1", "MockStashActor", "loaded", msg.NewBuilder(ctx).Build()))
+		select {
+		case mw := <-called:
+			assert.Equal(t, 6, msg.GetResCustomField[int](mw, "seq"))
+		case <-time.After(time.Second):
+			t.Fatal("stashed call did not return")
+		}
+
+		// replayed in their original order, ahead of the messages which arrived later
+		assert.Nil(t, sys.Call("stash-1", "MockStashActor", "get", get(7)))
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, sa.seqs())
+	})
+
+	t.Run("become and unbecome", func(t *testing.T) {
+		a, err := sys.Loader("MockStashActor").WithID("stash-2").Register(ctx)
+		assert.Nil(t, err)
+		sa := a.(*mockStashActor)
+
+		assert.Nil(t, sys.Call("stash-2", "MockStashActor", "loaded", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Call("stash-2", "MockStashActor", "get", get(1)))
+
+		assert.Nil(t, sys.Call("stash-2", "MockStashActor", "unload", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Send("stash-2", "MockStashActor", "get", get(2)))
+
+		// the loading behavior has no unload chain, the whole map was swapped and a single loaded goes back
+		assert.Nil(t, sys.Send("stash-2", "MockStashActor", "unload", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Call("stash-2", "MockStashActor", "loaded", msg.NewBuilder(ctx).Build()))
+		assert.Nil(t, sys.Call("stash-2", "MockStashActor", "get", get(3)))
+		assert.Equal(t, []int{1, 2, 3}, sa.seqs())
+	})
+
+	t.Run("stashed messages are dead-lettered on stop", func(t *testing.T) {
+		_, err := sys.Loader("MockStashActor").WithID("stash-3").Register(ctx)
+		assert.Nil(t, err)
+
+		called := make(chan *msg.Wrapper, 1)
+		go func() {
+			mw := get(1)
+			sys.Call("stash-3", "MockStashActor", "get", mw)
+			called <- mw
+		}()
+		time.Sleep(time.Millisecond * 100)
+
+		assert.Nil(t, sys.Unregister("stash-3", "MockStashActor"))
+		select {
+		case mw := <-called:
+			assert.NotNil(t, mw.Err)
+		case <-time.After(time.Second):
+			t.Fatal("stashed call did not return")
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSupervisedActor struct {
+	*actor.Runtime
+	counter int
+}
+
+func newMockSupervisedActor(p core.IActorBuilder) core.IActor {
+	return &mockSupervisedActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (sa *mockSupervisedActor) Init(ctx context.Context) {
+	sa.Runtime.Init(ctx)
+
+	sa.OnEvent("inc", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				sa.counter++
+				mw.ToBuilder().WithResCustomFields(msg.Attr{Key: "counter", Value: sa.counter})
+				return nil
+			},
+		}
+	})
+
+	sa.OnEvent("panic", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				panic("mock supervised actor panic")
+			},
+		}
+	})
+}
+
+func TestSupervisor(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockSupervisedActor"] = &core.ActorConstructor{
+		ID:          "MockSupervisedActor",
+		Name:        "MockSupervisedActor",
+		Weight:      20,
+		Constructor: newMockSupervisedActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID("test-supervisor-1"),
+		core.NodeWithLoader(loader),
+		core.NodeWithFactory(factory),
+	)
+
+	var err error
+	_, err = nod.System().Loader("MockSupervisedActor").WithID("supervised-resume").Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = nod.System().Loader("MockSupervisedActor").WithID("supervised-restart").
+		WithSupervisor(&core.SupervisorStrategy{
+			Directive:   core.SupervisorRestart,
+			MaxRestarts: 1,
+			Window:      time.Minute,
+			Backoff:     time.Millisecond * 10,
+		}).Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = nod.System().Loader("MockSupervisedActor").WithID("supervised-backoff").
+		WithMailbox(2, core.MailboxBlock).
+		WithSupervisor(&core.SupervisorStrategy{
+			Directive: core.SupervisorRestart,
+			Backoff:   time.Millisecond * 300,
+		}).Register(context.TODO())
+	assert.Nil(t, err)
+	_, err = nod.System().Loader("MockSupervisedActor").WithID("supervised-stop").
+		WithSupervisor(&core.SupervisorStrategy{Directive: core.SupervisorStop}).Register(context.TODO())
+	assert.Nil(t, err)
+
+	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
+
+	inc := func(id string) int {
+		m := msg.NewBuilder(context.TODO()).Build()
+		err := nod.System().Call(id, "MockSupervisedActor", "inc", m)
+		assert.Nil(t, err)
+		return msg.GetResCustomField[int](m, "counter")
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		inc("supervised-resume")
+		nod.System().Call("supervised-resume", "MockSupervisedActor", "panic", msg.NewBuilder(context.TODO()).Build())
+		assert.Equal(t, 2, inc("supervised-resume"))
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		inc("supervised-restart")
+		inc("supervised-restart")
+		nod.System().Call("supervised-restart", "MockSupervisedActor", "panic", msg.NewBuilder(context.TODO()).Build())
+		assert.Equal(t, 1, inc("supervised-restart")) // fresh state
+
+		// the second restart within the window exceeds MaxRestarts, the actor is stopped
+		nod.System().Call("supervised-restart", "MockSupervisedActor", "panic", msg.NewBuilder(context.TODO()).Build())
+		time.Sleep(time.Millisecond * 200)
+		_, err := nod.System().FindActor(context.TODO(), "supervised-restart")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		inc("supervised-backoff")
+		nod.System().Call("supervised-backoff", "MockSupervisedActor", "panic", msg.NewBuilder(context.TODO()).Build())
+
+		// the messages sent during the backoff are buffered, the senders blocked on the full mailbox
+		// are released by the handover to the fresh instance
+		begin := time.Now()
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.TODO(), time.Second*3)
+				defer cancel()
+				assert.Nil(t, nod.System().Send("supervised-backoff", "MockSupervisedActor", "inc", msg.NewBuilder(ctx).Build()))
+			}()
+		}
+		wg.Wait()
+		assert.Less(t, time.Since(begin), time.Second*2)
+
+		assert.Equal(t, 5, inc("supervised-backoff"))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		nod.System().Call("supervised-stop", "MockSupervisedActor", "panic", msg.NewBuilder(context.TODO()).Build())
+		time.Sleep(time.Millisecond * 200)
+		_, err := nod.System().FindActor(context.TODO(), "supervised-stop")
+		assert.NotNil(t, err)
+	})
+}
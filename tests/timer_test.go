@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -87,6 +88,12 @@ func TestActorTimer1(t *testing.T) {
 	)
 
 	nod.Init()
+	atomic.StoreInt32(&tick1, 0) // ticks fired while the node was starting are not counted
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
 
 	t.Run("tick1", func(t *testing.T) {
 		time.Sleep(time.Second * 5)
@@ -117,6 +124,12 @@ func TestActorTimer2(t *testing.T) {
 	)
 
 	nod.Init()
+	atomic.StoreInt32(&tick2, 0) // ticks fired while the node was starting are not counted
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
 
 	t.Run("tick2", func(t *testing.T) {
 		time.Sleep(time.Second * 5)
@@ -148,6 +161,12 @@ func TestActorTimer3(t *testing.T) {
 	)
 
 	nod.Init()
+	atomic.StoreInt32(&tick3, 0) // ticks fired while the node was starting are not counted
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
 
 	t.Run("tick3", func(t *testing.T) {
 		time.Sleep(time.Second * 5)
@@ -179,7 +198,13 @@ func TestActorTimer4(t *testing.T) {
 		core.NodeWithFactory(factory),
 	)
 
+	atomic.StoreInt32(&tick4, 0)
 	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
 
 	t.Run("tick4", func(t *testing.T) {
 		time.Sleep(time.Second * 3)
@@ -208,7 +233,13 @@ func TestActorTimer5(t *testing.T) {
 		core.NodeWithFactory(factory),
 	)
 
+	atomic.StoreInt32(&tick5, 0)
 	nod.Init()
+	defer func() {
+		wg := sync.WaitGroup{}
+		nod.System().Exit(&wg)
+		wg.Wait()
+	}()
 
 	t.Run("tick5", func(t *testing.T) {
 		time.Sleep(time.Second * 3)
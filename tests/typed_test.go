@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type addReq struct {
+	A, B int64
+}
+
+type addRes struct {
+	Sum int64
+}
+
+var errTypedOverflow = errors.New("sum overflow")
+
+type mockTypedActor struct {
+	*actor.Runtime
+}
+
+func newMockTypedActor(p core.IActorBuilder) core.IActor {
+	return &mockTypedActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (ta *mockTypedActor) Init(ctx context.Context) {
+	ta.Runtime.Init(ctx)
+
+	actor.OnRequest(ta, "add", func(ctx core.ActorContext, req *addReq) (*addRes, error) {
+		if req.A+req.B > 100 {
+			return nil, errTypedOverflow
+		}
+		return &addRes{Sum: req.A + req.B}, nil
+	})
+
+	actor.OnRequest(ta, "echo", func(ctx core.ActorContext, req *router.Header) (*router.Header, error) {
+		return &router.Header{Event: req.Event + "-echo", Timestamp: req.Timestamp + 1}, nil
+	}, actor.TypedWithCodec(&msg.ProtoSerialize{}))
+
+	// forwards the request to the other node's actor
+	actor.OnRequest(ta, "forward", func(ctx core.ActorContext, req *addReq) (*addRes, error) {
+		return actor.CallTyped[addReq, addRes](ctx, "typed-2", "MockTypedActor", "add", req)
+	})
+}
+
+func TestTypedCall(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockTypedActor"] = &core.ActorConstructor{
+		ID:          "MockTypedActor",
+		Name:        "MockTypedActor",
+		Weight:      20,
+		Constructor: newMockTypedActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+	loader := mock.BuildDefaultActorLoader(factory)
+
+	var nods []core.INode
+	for _, id := range []string{"typed-1", "typed-2"} {
+		p, _ := getFreePort()
+		nod := node.BuildProcessWithOption(
+			core.NodeWithID("test-"+id),
+			core.NodeWithPort(p),
+			core.NodeWithLoader(loader),
+			core.NodeWithFactory(factory),
+		)
+		nod.Init()
+
+		_, err := nod.System().Loader("MockTypedActor").WithID(id).Register(context.TODO())
+		assert.Nil(t, err)
+		nods = append(nods, nod)
+	}
+	defer func() {
+		for _, nod := range nods {
+			wg := sync.WaitGroup{}
+			nod.System().Exit(&wg)
+			wg.Wait()
+		}
+	}()
+
+	sys := nods[0].System()
+
+	t.Run("local", func(t *testing.T) {
+		res, err := actor.CallTyped[addReq, addRes](sys, "typed-1", "MockTypedActor", "add", &addReq{A: 1, B: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), res.Sum)
+
+		_, err = actor.CallTyped[addReq, addRes](sys, "typed-1", "MockTypedActor", "add", &addReq{A: 100, B: 2})
+		assert.True(t, errors.Is(err, errTypedOverflow), err)
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		res, err := actor.CallTyped[addReq, addRes](sys, "typed-2", "MockTypedActor", "add", &addReq{A: 3, B: 4})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), res.Sum)
+
+		// the handler error crosses the node boundary
+		_, err = actor.CallTyped[addReq, addRes](sys, "typed-1", "MockTypedActor", "forward", &addReq{A: 100, B: 2})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), errTypedOverflow.Error())
+	})
+
+	t.Run("proto", func(t *testing.T) {
+		res, err := actor.CallTyped[router.Header, router.Header](sys, "typed-2", "MockTypedActor", "echo",
+			&router.Header{Event: "ping", Timestamp: 1}, actor.TypedWithCodec(&msg.ProtoSerialize{}))
+		assert.Nil(t, err)
+		assert.Equal(t, "ping-echo", res.Event)
+		assert.Equal(t, int64(2), res.Timestamp)
+	})
+
+	t.Run("codec mismatch", func(t *testing.T) {
+		_, err := actor.CallTyped[addReq, addRes](sys, "typed-1", "MockTypedActor", "echo", &addReq{A: 1})
+		assert.NotNil(t, err)
+	})
+}
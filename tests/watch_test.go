@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pojol/braid/core"
+	"github.com/pojol/braid/core/actor"
+	"github.com/pojol/braid/core/node"
+	"github.com/pojol/braid/router/msg"
+	"github.com/pojol/braid/tests/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var watchTerminated = make(chan core.Terminated, 8)
+
+type mockWatchActor struct {
+	*actor.Runtime
+}
+
+func newMockWatchActor(p core.IActorBuilder) core.IActor {
+	return &mockWatchActor{
+		Runtime: &actor.Runtime{Id: p.GetID(), Ty: p.GetType(), Sys: p.GetSystem()},
+	}
+}
+
+func (wa *mockWatchActor) Init(ctx context.Context) {
+	wa.Runtime.Init(ctx)
+
+	wa.OnEvent("watch", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Watch(string(mw.Req.Body))
+			},
+		}
+	})
+
+	wa.OnEvent("unwatch", func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				return ctx.Unwatch(string(mw.Req.Body))
+			},
+		}
+	})
+
+	wa.OnEvent(core.TerminatedEvent, func(ctx core.ActorContext) core.IChain {
+		return &actor.DefaultChain{
+			Handler: func(mw *msg.Wrapper) error {
+				t, err := core.DecodeTerminated(mw.Req.Body)
+				if err != nil {
+					return err
+				}
+				watchTerminated <- t
+				return nil
+			},
+		}
+	})
+}
+
+func buildWatchNode(id string, factory *mock.MockActorFactory) core.INode {
+	port, _ := getFreePort()
+	nod := node.BuildProcessWithOption(
+		core.NodeWithID(id),
+		core.NodeWithPort(port),
+		core.NodeWithLoader(mock.BuildDefaultActorLoader(factory)),
+		core.NodeWithFactory(factory),
+	)
+	nod.Init()
+	return nod
+}
+
+func exitWatchNode(nod core.INode) {
+	wg := sync.WaitGroup{}
+	nod.System().Exit(&wg)
+	wg.Wait()
+}
+
+func TestWatch(t *testing.T) {
+	factory := mock.BuildActorFactory()
+	factory.Constructors["MockWatchActor"] = &core.ActorConstructor{
+		ID:          "MockWatchActor",
+		Name:        "MockWatchActor",
+		Weight:      20,
+		Constructor: newMockWatchActor,
+		Dynamic:     true,
+		Options:     make(map[string]string),
+	}
+
+	ctx := context.TODO()
+	targets := buildWatchNode("test-watch-1", factory)
+	watchers := buildWatchNode("test-watch-2", factory)
+	defer exitWatchNode(targets)
+
+	register := func(t *testing.T, nod core.INode, id string) {
+		_, err := nod.System().Loader("MockWatchActor").WithID(id).Register(ctx)
+		assert.Nil(t, err)
+	}
+
+	watch := func(t *testing.T, sys core.ISystem, event, target string) {
+		err := sys.Call("watcher", "MockWatchActor", event, msg.NewBuilder(ctx).WithReqBody([]byte(target)).Build())
+		assert.Nil(t, err)
+	}
+
+	expect := func(t *testing.T, id string, reason core.TerminatedReason) {
+		select {
+		case term := <-watchTerminated:
+			assert.Equal(t, id, term.ID)
+			assert.Equal(t, reason, term.Reason)
+		case <-time.After(time.Second * 2):
+			t.Fatalf("terminated of %v not received", id)
+		}
+	}
+
+	expectNone := func(t *testing.T) {
+		select {
+		case term := <-watchTerminated:
+			t.Fatalf("unexpected terminated %v", term)
+		case <-time.After(time.Millisecond * 300):
+		}
+	}
+
+	register(t, watchers, "watcher")
+
+	t.Run("unregistered", func(t *testing.T) {
+		register(t, targets, "watch-target-1")
+		watch(t, watchers.System(), "watch", "watch-target-1")
+
+		assert.Nil(t, targets.System().Unregister("watch-target-1", "MockWatchActor"))
+		expect(t, "watch-target-1", core.TerminatedUnregistered)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		watch(t, watchers.System(), "watch", "watch-target-missing")
+		expect(t, "watch-target-missing", core.TerminatedUnknown)
+	})
+
+	t.Run("unwatch", func(t *testing.T) {
+		register(t, targets, "watch-target-2")
+		watch(t, watchers.System(), "watch", "watch-target-2")
+		watch(t, watchers.System(), "unwatch", "watch-target-2")
+
+		assert.Nil(t, targets.System().Unregister("watch-target-2", "MockWatchActor"))
+		expectNone(t)
+	})
+
+	t.Run("watcher node restart", func(t *testing.T) {
+		register(t, targets, "watch-target-3")
+		watch(t, watchers.System(), "watch", "watch-target-3")
+
+		// the watch is kept in redis while the watcher moves to a new node
+		exitWatchNode(watchers)
+		watchers = buildWatchNode("test-watch-3", factory)
+		register(t, watchers, "watcher")
+
+		assert.Nil(t, targets.System().Unregister("watch-target-3", "MockWatchActor"))
+		expect(t, "watch-target-3", core.TerminatedUnregistered)
+	})
+
+	t.Run("node exit", func(t *testing.T) {
+		other := buildWatchNode("test-watch-4", factory)
+		register(t, other, "watch-target-4")
+		watch(t, watchers.System(), "watch", "watch-target-4")
+
+		exitWatchNode(other)
+		expect(t, "watch-target-4", core.TerminatedExit)
+	})
+
+	exitWatchNode(watchers)
+}